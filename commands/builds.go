@@ -52,6 +52,17 @@ func (command *BuildsCommand) Execute([]string) error {
 		}
 	}
 
+	var rangeUntil int
+	if command.Count < len(builds) {
+		rangeUntil = command.Count
+	} else {
+		rangeUntil = len(builds)
+	}
+
+	if Fly.JSON {
+		return displayhelpers.JSONPrint(append([]atc.Build{}, builds[:rangeUntil]...))
+	}
+
 	table := ui.Table{
 		Headers: ui.TableRow{
 			{Contents: "id", Color: color.New(color.Bold)},
@@ -64,13 +75,6 @@ func (command *BuildsCommand) Execute([]string) error {
 		},
 	}
 
-	var rangeUntil int
-	if command.Count < len(builds) {
-		rangeUntil = command.Count
-	} else {
-		rangeUntil = len(builds)
-	}
-
 	for _, b := range builds[:rangeUntil] {
 		startTimeCell, endTimeCell, durationCell := populateTimeCells(time.Unix(b.StartTime, 0), time.Unix(b.EndTime, 0))
 
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/concourse/go-concourse/concourse"
+	"github.com/fatih/color"
+)
+
+// newVersionsLimit is how many of the versions found by a check are listed.
+const newVersionsLimit = 100
+
+type CheckResourceCommand struct {
+	Resource flaghelpers.ResourceFlag `short:"r" long:"resource" required:"true" value-name:"PIPELINE/RESOURCE" description:"Name of the resource to check"`
+	Version  flaghelpers.VersionFlag  `          long:"from"                     value-name:"VERSION"           description:"Version of the resource to check from, e.g. ref:abcd or path:thing-1.2.3.tgz"`
+}
+
+func (command *CheckResourceCommand) Execute(args []string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	// only the versions newer than the latest one before the check were found
+	// by it
+	latest, _, found, err := client.ResourceVersions(
+		command.Resource.PipelineName,
+		command.Resource.ResourceName,
+		concourse.Page{Limit: 1},
+	)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		command.failNotFound()
+	}
+
+	var latestID int
+	if len(latest) > 0 {
+		latestID = latest[0].ID
+	}
+
+	found, err = client.CheckResource(
+		command.Resource.PipelineName,
+		command.Resource.ResourceName,
+		atc.Version(command.Version),
+	)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		command.failNotFound()
+	}
+
+	fmt.Printf("checked '%s'\n", command.Resource.ResourceName)
+
+	versions, _, _, err := client.ResourceVersions(
+		command.Resource.PipelineName,
+		command.Resource.ResourceName,
+		concourse.Page{Since: latestID, Limit: newVersionsLimit},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		fmt.Println("no new versions found")
+		return nil
+	}
+
+	fmt.Println("")
+	fmt.Println("new versions:")
+
+	return resourceVersionsTable(versions).Render(os.Stdout)
+}
+
+func (command *CheckResourceCommand) failNotFound() {
+	displayhelpers.Failf("pipeline '%s' or resource '%s' not found", command.Resource.PipelineName, command.Resource.ResourceName)
+}
+
+func resourceVersionsTable(versions []atc.VersionedResource) ui.Table {
+	table := ui.Table{
+		Headers: ui.TableRow{
+			{Contents: "id", Color: color.New(color.Bold)},
+			{Contents: "version", Color: color.New(color.Bold)},
+			{Contents: "enabled", Color: color.New(color.Bold)},
+		},
+	}
+
+	for _, v := range versions {
+		var enabledCell ui.TableCell
+		if v.Enabled {
+			enabledCell.Contents = "yes"
+		} else {
+			enabledCell.Contents = "no"
+			enabledCell.Color = color.New(color.FgRed)
+		}
+
+		table.Data = append(table.Data, ui.TableRow{
+			{Contents: strconv.Itoa(v.ID)},
+			versionCell(v.Version),
+			enabledCell,
+		})
+	}
+
+	return table
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/ui"
 	"github.com/fatih/color"
@@ -24,6 +25,16 @@ func (command *ContainersCommand) Execute([]string) error {
 		return err
 	}
 
+	sort.Sort(containersByHandle(containers))
+
+	if Fly.JSON {
+		if containers == nil {
+			containers = []atc.Container{}
+		}
+
+		return displayhelpers.JSONPrint(containers)
+	}
+
 	table := ui.Table{
 		Headers: ui.TableRow{
 			{Contents: "handle", Color: color.New(color.Bold)},
@@ -38,8 +49,6 @@ func (command *ContainersCommand) Execute([]string) error {
 		},
 	}
 
-	sort.Sort(containersByHandle(containers))
-
 	for _, c := range containers {
 		row := ui.TableRow{
 			{Contents: c.ID},
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+)
+
+type DeleteTargetCommand struct{}
+
+func (command *DeleteTargetCommand) Execute([]string) error {
+	if Fly.Target == "" {
+		return rc.ErrNoTargetSpecified
+	}
+
+	err := rc.DeleteTarget(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("deleted target '%s'\n", Fly.Target)
+
+	return nil
+}
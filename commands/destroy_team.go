@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+	"github.com/vito/go-interact/interact"
+)
+
+type DestroyTeamCommand struct {
+	TeamName        string `           long:"team-name" required:"true" description:"The team to destroy"`
+	SkipInteractive bool   `short:"n"  long:"non-interactive"           description:"Destroy the team without confirmation"`
+}
+
+func (command *DestroyTeamCommand) Execute(args []string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	teamName := command.TeamName
+	fmt.Printf("!!! this will remove all data for team `%s`\n\n", teamName)
+
+	confirm := command.SkipInteractive
+	if !confirm {
+		err := interact.NewInteraction("are you sure?").Resolve(&confirm)
+		if err != nil || !confirm {
+			fmt.Println("bailing out")
+			return err
+		}
+	}
+
+	found, err := client.DestroyTeam(teamName)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		fmt.Printf("`%s` does not exist\n", teamName)
+	} else {
+		fmt.Printf("`%s` deleted\n", teamName)
+	}
+
+	return nil
+}
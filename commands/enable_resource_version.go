@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/concourse/fly/commands/internal/displayhelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/rc"
+)
+
+type EnableResourceVersionCommand struct {
+	Resource  flaghelpers.ResourceFlag `short:"r" long:"resource"   required:"true" value-name:"PIPELINE/RESOURCE" description:"Name of the resource"`
+	VersionID int                      `short:"i" long:"version-id" required:"true" value-name:"ID"                description:"ID of the version to enable, as listed by resource-versions"`
+}
+
+func (command *EnableResourceVersionCommand) Execute([]string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	found, err := client.EnableResourceVersion(
+		command.Resource.PipelineName,
+		command.Resource.ResourceName,
+		command.VersionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		displayhelpers.Failf("pipeline/resource/version not found")
+	}
+
+	fmt.Printf("enabled version %d of '%s'\n", command.VersionID, command.Resource.ResourceName)
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/template"
+)
+
+type EncryptVarsCommand struct {
+	File        flaghelpers.PathFlag `short:"f" long:"file"         description:"The vars file to encrypt, or to decrypt with --decrypt"`
+	Decrypt     bool                 `short:"d" long:"decrypt"      description:"Decrypt the file rather than encrypting it"`
+	GenerateKey bool                 `          long:"generate-key" description:"Print a new key to set as $FLY_VARS_KEY"`
+}
+
+func (command *EncryptVarsCommand) Execute(args []string) error {
+	if command.GenerateKey {
+		key, err := template.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(key)
+		return nil
+	}
+
+	if command.File == "" {
+		return errors.New("a vars file (--file) must be specified")
+	}
+
+	key, err := template.ParseKey(os.Getenv(template.KeyEnv))
+	if err != nil {
+		return fmt.Errorf("invalid key in $%s: %s", template.KeyEnv, err)
+	}
+
+	contents, err := ioutil.ReadFile(string(command.File))
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	if command.Decrypt {
+		output, err = template.DecryptVariables(contents, key)
+	} else {
+		output, err = template.EncryptVariables(contents, key)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(output)
+	return err
+}
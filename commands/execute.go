@@ -1,11 +1,15 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/fly/commands/internal/executehelpers"
@@ -13,41 +17,191 @@ import (
 	"github.com/concourse/fly/config"
 	"github.com/concourse/fly/eventstream"
 	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/template"
 	"github.com/concourse/go-concourse/concourse"
 )
 
 type ExecuteCommand struct {
-	TaskConfig     flaghelpers.PathFlag         `short:"c" long:"config" required:"true"                description:"The task config to execute"`
+	TaskConfig     flaghelpers.PathFlag         `short:"c" long:"config"                                description:"The task config to execute"`
+	JobTask        flaghelpers.JobTaskFlag      `          long:"job-task"    value-name:"PIPELINE/JOB/STEP" description:"Execute a task step from a pipeline's job, with the job's inputs unless overridden with --input"`
 	Privileged     bool                         `short:"p" long:"privileged"                            description:"Run the task with full privileges"`
 	ExcludeIgnored bool                         `short:"x" long:"exclude-ignored"                       description:"Skip uploading .gitignored paths. This uses the file paths that are in your Git index. Make sure it's up to date!"`
+	Excludes       []string                     `          long:"exclude"     value-name:"PATTERN"      description:"Skip uploading paths matching the pattern, in .gitignore syntax, in addition to those listed in each input's .flyignore (can be specified multiple times)"`
+	UploadAttempts int                          `          long:"upload-attempts" default:"5"           description:"Number of times to try uploading each input before aborting the build. An upload that fails after sending part of the input is not retried, since the task has already read that part"`
 	Inputs         []flaghelpers.InputPairFlag  `short:"i" long:"input"       value-name:"NAME=PATH"    description:"An input to provide to the task (can be specified multiple times)"`
 	InputsFrom     flaghelpers.JobFlag          `short:"j" long:"inputs-from" value-name:"PIPELINE/JOB" description:"A job to base the inputs on"`
 	Outputs        []flaghelpers.OutputPairFlag `short:"o" long:"output"      value-name:"NAME=PATH"    description:"An output to fetch from the task (can be specified multiple times)"`
 	Tags           []string                     `          long:"tag"         value-name:"TAG"          description:"A tag for a specific environment (can be specified multiple times)"`
+	OutputFormat   string                       `          long:"output-format" default:"text" choice:"text" choice:"jsonl" description:"Render events as text, or as one JSON object per line"`
+	Timestamps     string                       `          long:"timestamps" choice:"elapsed" choice:"wall"                  description:"Prefix each log line with the time since the build started, or the wall-clock time"`
+	Cache          bool                         `          long:"cache"                                                      description:"Keep the archive of each input, and reuse it on the next run rather than archiving the input again if it has not changed"`
+	StepNames      bool                         `          long:"step-names"                                                 description:"Prefix each log line with the name of the step that printed it"`
+	Watch          bool                         `short:"w" long:"watch"                                                      description:"Run the task again whenever the task config or a local input changes"`
+	WatchInterval  time.Duration                `          long:"watch-interval" default:"5s"                                description:"How often to check the task config and local inputs for changes with --watch"`
+
+	InputsFromBuild flaghelpers.JobBuildFlag       `          long:"inputs-from-build" value-name:"PIPELINE/JOB/BUILD" description:"A build of a job to take the inputs' versions from"`
+	InputVersions   []flaghelpers.InputVersionFlag `          long:"input-version" value-name:"NAME=VERSION" description:"Pin an input taken from a job to a version, e.g. repo=ref:abc123 (can be specified multiple times)"`
+
+	Params        []flaghelpers.VariablePairFlag `          long:"param"       value-name:"NAME=VALUE"   description:"Set one of the task's params (can be specified multiple times)"`
+	ParamsFrom    flaghelpers.PathFlag           `          long:"params-from" value-name:"PATH"         description:"Set the task's params from a YAML file of names to values"`
+	Image         string                         `          long:"image"       value-name:"URL"          description:"Run the task in this image instead of the one it configures"`
+	ImageResource flaghelpers.PathFlag           `          long:"image-resource" value-name:"PATH"      description:"Run the task in the image fetched by the resource, given by a YAML file with a type and a source"`
+
+	Var           []flaghelpers.VariablePairFlag `short:"v" long:"var"         value-name:"NAME=VALUE"   description:"Fill in a template variable in the task config (can be specified multiple times)"`
+	VarsFrom      []flaghelpers.PathFlag         `short:"l" long:"load-vars-from" value-name:"PATH"      description:"Fill in template variables in the task config from a YAML file (can be specified multiple times)"`
+	VarsProviders []string                       `          long:"vars-provider" value-name:"SPEC"       description:"Resolve template variables in the task config from env[:PREFIX], encrypted:PATH or command:COMMAND, instead of the target's providers, which are only used along with --var or --load-vars-from (can be specified multiple times)"`
+
+	notifyOnce sync.Once
 }
 
 func (command *ExecuteCommand) Execute(args []string) error {
+	if command.TaskConfig == "" && command.JobTask.PipelineName == "" {
+		return errors.New("either a task config (--config) or a job's task (--job-task) must be specified")
+	}
+
+	if command.TaskConfig != "" && command.JobTask.PipelineName != "" {
+		return errors.New("--config and --job-task cannot be used together")
+	}
+
+	err := validateRenderOptions(command.OutputFormat, command.renderOptions())
+	if err != nil {
+		return err
+	}
+
 	client, err := rc.TargetClient(Fly.Target)
 	if err != nil {
 		return err
 	}
 
-	taskConfigFile := command.TaskConfig
-	excludeIgnored := command.ExcludeIgnored
+	interrupted := make(chan struct{})
+
+	if command.Watch {
+		exitCode, err := command.watch(client, args, interrupted)
+		if err != nil {
+			return err
+		}
+
+		os.Exit(exitCode)
+		return nil
+	}
 
-	taskConfig, err := config.LoadTaskConfig(string(taskConfigFile), args)
+	exitCode, _, err := command.run(client, args, interrupted, nil)
 	if err != nil {
 		return err
 	}
 
+	os.Exit(exitCode)
+
+	return nil
+}
+
+// watch runs the task, and runs it again whenever the task config or a local
+// input changes, aborting the current build if it's still running. It
+// returns once interrupted.
+func (command *ExecuteCommand) watch(client concourse.Client, args []string, interrupted chan struct{}) (int, error) {
+	dirs := []string{}
+	for _, input := range command.Inputs {
+		dirs = append(dirs, input.Path)
+	}
+
+	if len(command.Inputs) == 0 && command.InputsFrom.PipelineName == "" && command.InputsFromBuild.PipelineName == "" && command.JobTask.PipelineName == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return 0, err
+		}
+
+		dirs = append(dirs, wd)
+	}
+
+	files := []string{}
+	for _, file := range []flaghelpers.PathFlag{command.TaskConfig, command.ParamsFrom, command.ImageResource} {
+		if file != "" {
+			files = append(files, string(file))
+		}
+	}
+
+	watcher := executehelpers.NewWatcher(
+		dirs,
+		files,
+		command.ExcludeIgnored,
+		command.Excludes,
+		command.WatchInterval,
+	)
+
+	changes := watcher.Watch(interrupted)
+
+	for {
+		exitCode, changed, err := command.run(client, args, interrupted, changes)
+		if err != nil {
+			// e.g. an invalid task config; it may be fixed by the next change
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+
+		select {
+		case <-interrupted:
+			return exitCode, nil
+		default:
+		}
+
+		if changed {
+			fmt.Fprintln(os.Stderr, "\nchange detected; running again")
+			continue
+		}
+
+		fmt.Fprintln(os.Stderr, "\nwaiting for changes...")
+
+		select {
+		case <-changes:
+			fmt.Fprintln(os.Stderr, "change detected; running again")
+		case <-interrupted:
+			return exitCode, nil
+		}
+	}
+}
+
+// run creates and runs a single build of the task, returning the exit code
+// for its status. The build is aborted if interrupted is closed or, returning
+// changed, if anything is sent on changes.
+func (command *ExecuteCommand) run(
+	client concourse.Client,
+	args []string,
+	interrupted chan struct{},
+	changes <-chan struct{},
+) (int, bool, error) {
+	taskConfig, privileged, jobInputMapping, err := command.loadTaskConfig(client, args)
+	if err != nil {
+		return 0, false, err
+	}
+
+	taskConfig, err = executehelpers.OverrideParams(taskConfig, command.ParamsFrom, command.Params)
+	if err != nil {
+		return 0, false, err
+	}
+
+	taskConfig, err = executehelpers.OverrideImage(taskConfig, command.Image, command.ImageResource)
+	if err != nil {
+		return 0, false, err
+	}
+
+	inputsFrom := command.InputsFrom
+	if inputsFrom.PipelineName == "" && command.InputsFromBuild.PipelineName == "" && command.JobTask.PipelineName != "" {
+		inputsFrom = flaghelpers.JobFlag{
+			PipelineName: command.JobTask.PipelineName,
+			JobName:      command.JobTask.JobName,
+		}
+	}
+
 	inputs, err := executehelpers.DetermineInputs(
 		client,
 		taskConfig.Inputs,
 		command.Inputs,
-		command.InputsFrom,
+		inputsFrom,
+		command.InputsFromBuild,
+		command.InputVersions,
+		jobInputMapping,
 	)
 	if err != nil {
-		return err
+		return 0, false, err
 	}
 
 	outputs, err := executehelpers.DetermineOutputs(
@@ -56,12 +210,12 @@ func (command *ExecuteCommand) Execute(args []string) error {
 		command.Outputs,
 	)
 	if err != nil {
-		return err
+		return 0, false, err
 	}
 
 	build, err := executehelpers.CreateBuild(
 		client,
-		command.Privileged,
+		privileged,
 		inputs,
 		outputs,
 		taskConfig,
@@ -69,34 +223,72 @@ func (command *ExecuteCommand) Execute(args []string) error {
 		Fly.Target,
 	)
 	if err != nil {
-		return err
+		return 0, false, err
 	}
 
-	fmt.Println("executing build", build.ID)
+	if command.OutputFormat == "jsonl" {
+		fmt.Fprintln(os.Stderr, "executing build", build.ID)
+	} else {
+		fmt.Println("executing build", build.ID)
+	}
 
-	terminate := make(chan os.Signal, 1)
+	command.notifyOnce.Do(func() {
+		go interruptOnSignal(interrupted)
+	})
 
-	go abortOnSignal(client, terminate, build)
+	finished := make(chan struct{})
 
-	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+	changedChan := make(chan bool, 1)
+	go func() {
+		select {
+		case <-interrupted:
+			abortBuild(client, build)
+			changedChan <- false
+		case <-changes:
+			fmt.Fprintf(os.Stderr, "\nchange detected; aborting build %d\n", build.ID)
+			abortBuild(client, build)
+			changedChan <- true
+		case <-finished:
+			changedChan <- false
+		}
+	}()
+
+	uploadOptions := executehelpers.UploadOptions{
+		ExcludeIgnored: command.ExcludeIgnored,
+		Excludes:       command.Excludes,
+		Attempts:       command.UploadAttempts,
+	}
+
+	if command.Cache {
+		uploadOptions.CacheDir = filepath.Join(rc.CacheDir(), "inputs")
+	}
 
-	inputChan := make(chan interface{})
+	inputChan := make(chan error, 1)
 	go func() {
 		for _, i := range inputs {
 			if i.Path != "" {
-				executehelpers.Upload(client, i, excludeIgnored)
+				err := executehelpers.Upload(client, i, uploadOptions)
+				if err != nil {
+					// the error is returned once the build's events end
+					abortBuild(client, build)
+					inputChan <- err
+					return
+				}
 			}
 		}
 		close(inputChan)
 	}()
 
-	var outputChans []chan (interface{})
+	var outputChans []chan error
 	if len(outputs) > 0 {
 		for i, output := range outputs {
-			outputChans = append(outputChans, make(chan interface{}, 1))
-			go func(o executehelpers.Output, outputChan chan<- interface{}) {
+			outputChans = append(outputChans, make(chan error, 1))
+			go func(o executehelpers.Output, outputChan chan<- error) {
 				if o.Path != "" {
-					executehelpers.Download(client, o)
+					err := executehelpers.Download(client, o)
+					if err != nil {
+						outputChan <- fmt.Errorf("failed to download output '%s': %s", o.Name, err)
+					}
 				}
 
 				close(outputChan)
@@ -106,42 +298,136 @@ func (command *ExecuteCommand) Execute(args []string) error {
 
 	eventSource, err := client.BuildEvents(fmt.Sprintf("%d", build.ID))
 	if err != nil {
-		return err
+		close(finished)
+		return 0, false, err
 	}
 
-	exitCode := eventstream.Render(os.Stdout, eventSource)
+	exitCode := renderEvents(command.OutputFormat, command.renderOptions(), eventSource)
 	eventSource.Close()
 
-	<-inputChan
+	uploadErr := <-inputChan
 
-	if len(outputs) > 0 {
-		for _, outputChan := range outputChans {
-			<-outputChan
+	var downloadErr error
+	for _, outputChan := range outputChans {
+		err := <-outputChan
+		if err == nil {
+			continue
+		}
+
+		// the first failure is returned; report any others here
+		if downloadErr == nil {
+			downloadErr = err
+		} else {
+			fmt.Fprintln(os.Stderr, err)
 		}
 	}
 
-	os.Exit(exitCode)
+	close(finished)
+	changed := <-changedChan
 
-	return nil
+	if uploadErr != nil {
+		return exitCode, changed, uploadErr
+	}
+
+	if downloadErr != nil {
+		return exitCode, changed, downloadErr
+	}
+
+	return exitCode, changed, nil
 }
 
-func abortOnSignal(
-	client concourse.Client,
-	terminate <-chan os.Signal,
-	build atc.Build,
-) {
-	<-terminate
+func (command *ExecuteCommand) renderOptions() eventstream.RenderOptions {
+	return eventstream.RenderOptions{
+		Timestamps:    command.Timestamps,
+		ShowStepNames: command.StepNames,
+	}
+}
 
-	fmt.Fprintf(os.Stderr, "\naborting...\n")
+// loadTaskConfig loads the task config from the given file, filling in any
+// template variables, or from the pipeline when running a job's task,
+// returning whether to run it privileged and, for a job's task, the names of
+// the job's inputs that the task's inputs are mapped to.
+func (command *ExecuteCommand) loadTaskConfig(client concourse.Client, args []string) (atc.TaskConfig, bool, map[string]string, error) {
+	if command.JobTask.PipelineName != "" {
+		taskConfig, privileged, inputMapping, err := executehelpers.LoadJobTaskConfig(
+			client,
+			command.JobTask,
+			command.Inputs,
+			args,
+		)
+		if err != nil {
+			return atc.TaskConfig{}, false, nil, err
+		}
 
-	err := client.AbortBuild(strconv.Itoa(build.ID))
+		return taskConfig, privileged || command.Privileged, inputMapping, nil
+	}
+
+	// task configs are only templated when asked to, so that the target's
+	// providers don't take over text that merely looks like a variable
+	if len(command.VarsProviders) == 0 && len(command.Var) == 0 && len(command.VarsFrom) == 0 {
+		taskConfig, err := config.LoadTaskConfig(string(command.TaskConfig), args)
+		if err != nil {
+			return atc.TaskConfig{}, false, nil, err
+		}
+
+		return taskConfig, command.Privileged, nil, nil
+	}
+
+	target, err := rc.SelectTarget(Fly.Target)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "failed to abort:", err)
-		return
+		return atc.TaskConfig{}, false, nil, err
+	}
+
+	providers, err := parseVarsProviders(command.VarsProviders, target)
+	if err != nil {
+		return atc.TaskConfig{}, false, nil, err
+	}
+
+	variables := template.Variables{}
+	for _, path := range command.VarsFrom {
+		fileVars, err := template.LoadVariablesFromFile(string(path))
+		if err != nil {
+			return atc.TaskConfig{}, false, nil, fmt.Errorf("failed to load variables from file (%s): %s", string(path), err)
+		}
+
+		variables = variables.Merge(fileVars)
 	}
 
+	for _, v := range command.Var {
+		variables[v.Name] = v.Value
+	}
+
+	taskConfig, err := config.LoadTemplatedTaskConfig(string(command.TaskConfig), args, providers, variables)
+	if err != nil {
+		return atc.TaskConfig{}, false, nil, err
+	}
+
+	return taskConfig, command.Privileged, nil, nil
+}
+
+// interruptOnSignal closes interrupted on the first SIGINT or SIGTERM, so
+// that the running build is aborted, and exits on the second.
+func interruptOnSignal(interrupted chan<- struct{}) {
+	terminate := make(chan os.Signal, 1)
+	signal.Notify(terminate, syscall.SIGINT, syscall.SIGTERM)
+
+	<-terminate
+	close(interrupted)
+
 	// if told to terminate again, exit immediately
 	<-terminate
 	fmt.Fprintln(os.Stderr, "exiting immediately")
 	os.Exit(2)
 }
+
+func abortBuild(client concourse.Client, build atc.Build) error {
+	fmt.Fprintf(os.Stderr, "\naborting...\n")
+
+	err := client.AbortBuild(strconv.Itoa(build.ID))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to abort:", err)
+		return err
+	}
+
+	return nil
+}
@@ -4,13 +4,21 @@ import "github.com/concourse/fly/rc"
 
 type FlyCommand struct {
 	Target rc.TargetName `short:"t" long:"target" description:"Concourse target name"`
+	JSON   bool          `          long:"json"   description:"Print the results of commands that list things as JSON"`
 
 	Version func() `short:"v" long:"version" description:"Print the version of Fly and exit"`
 
-	Login LoginCommand `command:"login" alias:"l" description:"Authenticate with the target"`
-	Sync  SyncCommand  `command:"sync"  alias:"s" description:"Download and replace the current fly from the target"`
+	Login  LoginCommand  `command:"login"  alias:"l" description:"Authenticate with the target"`
+	Logout LogoutCommand `command:"logout" alias:"o" description:"Release authentication with the target"`
+	Sync   SyncCommand   `command:"sync"   alias:"s" description:"Download and replace the current fly from the target"`
 
-	SetTeam SetTeamCommand `hidden:"yes" command:"set-team"  alias:"st" description:"Create or modify a team to have the given credentials"`
+	Targets      TargetsCommand      `command:"targets"       alias:"ts" description:"List saved targets"`
+	DeleteTarget DeleteTargetCommand `command:"delete-target" alias:"dtg" description:"Delete a saved target"`
+	RenameTarget RenameTargetCommand `command:"rename-target" alias:"rt" description:"Rename a saved target"`
+
+	SetTeam     SetTeamCommand     `hidden:"yes" command:"set-team"     alias:"st"  description:"Create or modify a team to have the given credentials"`
+	Teams       TeamsCommand       `             command:"teams"        alias:"tms" description:"List the configured teams"`
+	DestroyTeam DestroyTeamCommand `             command:"destroy-team" alias:"dt"  description:"Destroy a team and all of its pipelines and builds"`
 
 	Checklist ChecklistCommand `command:"checklist" alias:"cl" description:"Print a Checkfile of the given pipeline"`
 
@@ -22,6 +30,7 @@ type FlyCommand struct {
 
 	PauseJob   PauseJobCommand   `command:"pause-job" alias:"pj" description:"Pause a job"`
 	UnpauseJob UnpauseJobCommand `command:"unpause-job" alias:"uj" description:"Unpause a job"`
+	TriggerJob TriggerJobCommand `command:"trigger-job" alias:"tj" description:"Start a job in a pipeline"`
 
 	Pipelines       PipelinesCommand       `command:"pipelines"        alias:"ps" description:"List the configured pipelines"`
 	DestroyPipeline DestroyPipelineCommand `command:"destroy-pipeline" alias:"dp" description:"Destroy a pipeline"`
@@ -30,6 +39,13 @@ type FlyCommand struct {
 	PausePipeline   PausePipelineCommand   `command:"pause-pipeline"   alias:"pp" description:"Pause a pipeline"`
 	UnpausePipeline UnpausePipelineCommand `command:"unpause-pipeline" alias:"up" description:"Un-pause a pipeline"`
 
+	EncryptVars EncryptVarsCommand `command:"encrypt-vars" alias:"ev" description:"Encrypt a vars file for the encrypted vars provider"`
+
+	CheckResource          CheckResourceCommand          `command:"check-resource"           alias:"cr"  description:"Check a resource"`
+	ResourceVersions       ResourceVersionsCommand       `command:"resource-versions"        alias:"rvs" description:"List the versions of a resource"`
+	EnableResourceVersion  EnableResourceVersionCommand  `command:"enable-resource-version"  alias:"erv" description:"Enable a version of a resource"`
+	DisableResourceVersion DisableResourceVersionCommand `command:"disable-resource-version" alias:"drv" description:"Disable a version of a resource"`
+
 	Builds     BuildsCommand     `command:"builds" alias:"bs" description:"List builds data"`
 	AbortBuild AbortBuildCommand `command:"abort-build" alias:"ab" description:"Abort a build"`
 
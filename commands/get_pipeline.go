@@ -12,11 +12,11 @@ import (
 
 type GetPipelineCommand struct {
 	Pipeline string `short:"p" long:"pipeline" required:"true" description:"Get configuration of this pipeline"`
-	JSON     bool   `short:"j" long:"json"                     description:"Print config as json instead of yaml"`
+	JSON     bool   `short:"j"                                 description:"Print config as json instead of yaml, like --json"`
 }
 
 func (command *GetPipelineCommand) Execute(args []string) error {
-	asJSON := command.JSON
+	asJSON := command.JSON || Fly.JSON
 	pipelineName := command.Pipeline
 
 	client, err := rc.TargetClient(Fly.Target)
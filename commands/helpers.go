@@ -3,10 +3,15 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/template"
 	"github.com/concourse/go-concourse/concourse"
+	concourseeventstream "github.com/concourse/go-concourse/concourse/eventstream"
 )
 
 func GetBuild(client concourse.Client, jobName string, buildNameOrID string, pipelineName string) (atc.Build, error) {
@@ -80,3 +85,41 @@ func SliceItoa(slice []int) string {
 	}
 	return strSlice
 }
+
+// validateRenderOptions rejects log prefixes with jsonl output, where each
+// event's time and origin are part of the JSON instead.
+func validateRenderOptions(outputFormat string, options eventstream.RenderOptions) error {
+	if outputFormat == "jsonl" && (options.Timestamps != eventstream.TimestampsNone || options.ShowStepNames) {
+		return errors.New("--timestamps and --step-names cannot be used with --output-format jsonl")
+	}
+
+	return nil
+}
+
+func renderEvents(outputFormat string, options eventstream.RenderOptions, eventSource concourseeventstream.EventStream) int {
+	if outputFormat == "jsonl" {
+		return eventstream.RenderJSONL(os.Stdout, eventSource)
+	}
+
+	return eventstream.RenderWithOptions(os.Stdout, eventSource, options)
+}
+
+// parseVarsProviders returns the template variable providers given by flags,
+// or else those configured for the target.
+func parseVarsProviders(specs []string, target rc.TargetProps) ([]template.Provider, error) {
+	if len(specs) == 0 {
+		specs = target.VarsProviders
+	}
+
+	providers := []template.Provider{}
+	for _, spec := range specs {
+		provider, err := template.ParseProvider(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
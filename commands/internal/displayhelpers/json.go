@@ -0,0 +1,16 @@
+package displayhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func JSONPrint(thing interface{}) error {
+	bytes, err := json.MarshalIndent(thing, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Printf("%s\n", bytes)
+	return err
+}
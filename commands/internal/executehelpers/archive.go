@@ -0,0 +1,183 @@
+package executehelpers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ArchiveStreamFrom archives the given paths under workDir, compressing them
+// on every available CPU. Unless flat is set, directories are archived along
+// with everything beneath them.
+func ArchiveStreamFrom(workDir string, paths []string, flat bool) (io.ReadCloser, error) {
+	return archiveStream(workDir, paths, flat, newCompressor, ioutil.Discard, ioutil.Discard)
+}
+
+// newCompressor returns the gzip writer inputs are compressed with.
+func newCompressor(w io.Writer) io.WriteCloser {
+	// compressing in parallel only costs more on a single CPU
+	if runtime.NumCPU() > 1 {
+		return newParallelGzipWriter(w)
+	}
+
+	return gzip.NewWriter(w)
+}
+
+// archiveStream is ArchiveStreamFrom, compressing with the given compressor,
+// with the contents of each file also written to counter as it's archived,
+// and each entry written to fingerprint just as Fingerprint hashes it.
+func archiveStream(workDir string, paths []string, flat bool, compress func(io.Writer) io.WriteCloser, counter io.Writer, fingerprint io.Writer) (io.ReadCloser, error) {
+	absWorkDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+
+	compressor := compress(w)
+	tarWriter := tar.NewWriter(compressor)
+
+	go func() {
+		err := writePathsToTar(tarWriter, absWorkDir, paths, flat, counter, fingerprint)
+
+		if closeErr := tarWriter.Close(); err == nil {
+			err = closeErr
+		}
+
+		if closeErr := compressor.Close(); err == nil {
+			err = closeErr
+		}
+
+		w.CloseWithError(err)
+	}()
+
+	return r, nil
+}
+
+// contentSize returns the total size of the files that archiving the given
+// paths would write, as counted by archiveStream.
+func contentSize(workDir string, paths []string, flat bool) (int64, error) {
+	var size int64
+
+	add := func(info os.FileInfo) {
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+	}
+
+	for _, p := range paths {
+		path := filepath.Join(workDir, p)
+
+		if flat {
+			info, err := os.Lstat(path)
+			if err != nil {
+				return 0, err
+			}
+
+			add(info)
+			continue
+		}
+
+		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			add(info)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
+
+func writePathsToTar(tw *tar.Writer, workDir string, paths []string, flat bool, counter io.Writer, fingerprint io.Writer) error {
+	for _, p := range paths {
+		var err error
+		if flat {
+			err = addTarFile(filepath.Join(workDir, p), p, tw, counter, fingerprint)
+		} else {
+			err = writePathToTar(tw, workDir, filepath.Join(workDir, p), counter, fingerprint)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePathToTar(tw *tar.Writer, workDir string, srcPath string, counter io.Writer, fingerprint io.Writer) error {
+	return filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(workDir, path)
+		if err != nil {
+			return err
+		}
+
+		return addTarFile(path, relative, tw, counter, fingerprint)
+	})
+}
+
+func addTarFile(path, name string, tw *tar.Writer, counter io.Writer, fingerprint io.Writer) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if fi.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return err
+	}
+
+	fingerprintEntry(fingerprint, name, fi, link)
+
+	if fi.IsDir() && !os.IsPathSeparator(name[len(name)-1]) {
+		name = name + "/"
+	}
+
+	if hdr.Typeflag == tar.TypeReg && name == "." {
+		// archiving a single file
+		hdr.Name = filepath.ToSlash(filepath.Base(path))
+	} else {
+		hdr.Name = filepath.ToSlash(name)
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if hdr.Typeflag == tar.TypeReg {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		_, err = io.Copy(tw, io.TeeReader(file, io.MultiWriter(counter, fingerprint)))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package executehelpers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+)
+
+var _ = Describe("ArchiveStreamFrom", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-archive")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "sub", "script"), []byte("#!/bin/sh\n"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "data"), []byte("some data"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.Symlink("sub/script", filepath.Join(dir, "link"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	readEntries := func(r io.Reader) map[string]*tar.Header {
+		entries := map[string]*tar.Header{}
+
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+
+			entries[hdr.Name] = hdr
+		}
+
+		return entries
+	}
+
+	decompress := func(r io.Reader) io.Reader {
+		gr, err := gzip.NewReader(r)
+		Expect(err).NotTo(HaveOccurred())
+		return gr
+	}
+
+	It("keeps file modes and symlinks", func() {
+		archive, err := executehelpers.ArchiveStreamFrom(dir, []string{"."}, false)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer archive.Close()
+
+		entries := readEntries(decompress(archive))
+
+		Expect(entries).To(HaveKey("./"))
+		Expect(entries).To(HaveKey("sub/"))
+
+		Expect(entries).To(HaveKey("sub/script"))
+		Expect(entries["sub/script"].FileInfo().Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+		Expect(entries).To(HaveKey("data"))
+		Expect(entries["data"].FileInfo().Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		Expect(entries).To(HaveKey("link"))
+		Expect(entries["link"].Typeflag).To(Equal(byte(tar.TypeSymlink)))
+		Expect(entries["link"].Linkname).To(Equal("sub/script"))
+	})
+
+	Context("when the input spans many compression blocks", func() {
+		var contents []byte
+
+		BeforeEach(func() {
+			contents = make([]byte, 5<<20+123)
+			rand.New(rand.NewSource(0)).Read(contents)
+
+			err := ioutil.WriteFile(filepath.Join(dir, "big"), contents, 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("can be read back as a single gzip stream when compressed in parallel", func() {
+			archive, err := executehelpers.ArchiveStreamParallelGzip(dir, []string{"big"}, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			defer archive.Close()
+
+			tr := tar.NewReader(decompress(archive))
+
+			hdr, err := tr.Next()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hdr.Name).To(Equal("big"))
+
+			read, err := ioutil.ReadAll(tr)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bytes.Equal(read, contents)).To(BeTrue())
+		})
+	})
+
+	Context("when flat", func() {
+		It("only archives the listed paths", func() {
+			archive, err := executehelpers.ArchiveStreamFrom(dir, []string{".", "data"}, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			defer archive.Close()
+
+			gr, err := gzip.NewReader(archive)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries := readEntries(gr)
+			Expect(entries).To(HaveLen(2))
+			Expect(entries).To(HaveKey("./"))
+			Expect(entries).To(HaveKey("data"))
+		})
+	})
+
+	Context("when a path does not exist", func() {
+		It("fails the stream", func() {
+			archive, err := executehelpers.ArchiveStreamFrom(dir, []string{"bogus"}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			defer archive.Close()
+
+			_, err = ioutil.ReadAll(archive)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+})
+
+// benchmarkInput creates a mix of compressible sources and incompressible
+// build artefacts, roughly the shape of a typical input.
+func benchmarkInput(b *testing.B) string {
+	dir, err := ioutil.TempDir("", "fly-archive-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < 64; i++ {
+		source := bytes.Repeat([]byte("func main() { println(\"hello\") }\n"), 4096)
+
+		err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("source-%d.go", i)), source, 0644)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	random := rand.New(rand.NewSource(0))
+
+	for i := 0; i < 8; i++ {
+		artefact := make([]byte, 4<<20)
+		random.Read(artefact)
+
+		err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("artefact-%d.bin", i)), artefact, 0644)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func benchmarkArchive(b *testing.B, archive func(dir string) (io.ReadCloser, error)) {
+	dir := benchmarkInput(b)
+	defer os.RemoveAll(dir)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		stream, err := archive(dir)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		n, err := io.Copy(ioutil.Discard, stream)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		stream.Close()
+
+		b.SetBytes(n)
+	}
+}
+
+// BenchmarkArchiveSystemTar measures the system tar with serial gzip, which
+// is what inputs were archived with before.
+func BenchmarkArchiveSystemTar(b *testing.B) {
+	tarPath, err := exec.LookPath("tar")
+	if err != nil {
+		b.Skip("tar is not installed")
+	}
+
+	benchmarkArchive(b, func(dir string) (io.ReadCloser, error) {
+		tarCmd := exec.Command(tarPath, "-czf", "-", ".")
+		tarCmd.Dir = dir
+
+		out, err := tarCmd.Output()
+		if err != nil {
+			return nil, err
+		}
+
+		return ioutil.NopCloser(bytes.NewReader(out)), nil
+	})
+}
+
+// BenchmarkArchiveSerialGzip measures the native archiver compressing on a
+// single CPU, to tell the gain from compressing in parallel apart from the
+// gain from not running tar.
+func BenchmarkArchiveSerialGzip(b *testing.B) {
+	benchmarkArchive(b, func(dir string) (io.ReadCloser, error) {
+		return executehelpers.ArchiveStreamSerialGzip(dir, []string{"."}, false)
+	})
+}
+
+// BenchmarkArchiveParallelGzip measures the native archiver compressing on
+// every available CPU, which it does when there is more than one.
+func BenchmarkArchiveParallelGzip(b *testing.B) {
+	benchmarkArchive(b, func(dir string) (io.ReadCloser, error) {
+		return executehelpers.ArchiveStreamParallelGzip(dir, []string{"."}, false)
+	})
+}
@@ -0,0 +1,264 @@
+package executehelpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxCachedArchives is how many archives are kept, across every input, before
+// the least recently used are removed.
+const maxCachedArchives = 8
+
+// inputCache keeps the last archive uploaded for each input directory, keyed
+// by a fingerprint of the files that went into it. The ATC cannot replay a
+// pipe, so a hit still uploads the archive; it only saves building it again.
+type inputCache struct {
+	dir string
+}
+
+func (cache inputCache) enabled() bool {
+	return cache.dir != ""
+}
+
+// archivePath returns where the archive for the input at inputPath with the
+// given fingerprint lives. Archives for the same input share a prefix so that
+// stale ones can be found and removed.
+func (cache inputCache) archivePath(inputPath string, fingerprint string) string {
+	return filepath.Join(cache.dir, cache.inputKey(inputPath)+"-"+fingerprint+".tgz")
+}
+
+func (cache inputCache) inputKey(inputPath string) string {
+	absPath, err := filepath.Abs(inputPath)
+	if err != nil {
+		absPath = inputPath
+	}
+
+	sum := sha256.Sum256([]byte(absPath))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lookup opens the cached archive for the fingerprint, if there is one.
+func (cache inputCache) lookup(inputPath string, fingerprint string) (*os.File, int64, bool) {
+	if !cache.enabled() {
+		return nil, 0, false
+	}
+
+	archive, err := os.Open(cache.archivePath(inputPath, fingerprint))
+	if err != nil {
+		return nil, 0, false
+	}
+
+	info, err := archive.Stat()
+	if err != nil {
+		archive.Close()
+		return nil, 0, false
+	}
+
+	// mark it as recently used, so that it outlives other inputs' archives
+	now := time.Now()
+	os.Chtimes(archive.Name(), now, now)
+
+	return archive, info.Size(), true
+}
+
+// record tees the archive being built into a temporary file, which commit
+// moves into place once the whole archive has been read and uploaded.
+func (cache inputCache) record(inputPath string, archive io.ReadCloser) (*cacheRecorder, error) {
+	err := os.MkdirAll(cache.dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpFile, err := ioutil.TempFile(cache.dir, ".archive")
+	if err != nil {
+		return nil, err
+	}
+
+	return &cacheRecorder{
+		ReadCloser: archive,
+
+		cache:     cache,
+		inputPath: inputPath,
+		file:      tmpFile,
+	}, nil
+}
+
+type cacheRecorder struct {
+	io.ReadCloser
+
+	cache     inputCache
+	inputPath string
+
+	file     *os.File
+	complete bool
+	failed   bool
+}
+
+func (recorder *cacheRecorder) Read(p []byte) (int, error) {
+	n, err := recorder.ReadCloser.Read(p)
+
+	if n > 0 && !recorder.failed {
+		_, writeErr := recorder.file.Write(p[:n])
+		if writeErr != nil {
+			recorder.failed = true
+		}
+	}
+
+	if err == io.EOF {
+		recorder.complete = true
+	}
+
+	return n, err
+}
+
+// commit keeps the recorded archive under the fingerprint of what went into
+// it, replacing any older archive of the same input. It does nothing if the
+// archive was not read to the end.
+func (recorder *cacheRecorder) commit(fingerprint string) {
+	err := recorder.file.Close()
+	if err != nil || !recorder.complete || recorder.failed {
+		os.Remove(recorder.file.Name())
+		return
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(recorder.cache.dir, recorder.cache.inputKey(recorder.inputPath)+"-*"))
+	for _, path := range stale {
+		os.Remove(path)
+	}
+
+	err = os.Rename(recorder.file.Name(), recorder.cache.archivePath(recorder.inputPath, fingerprint))
+	if err != nil {
+		os.Remove(recorder.file.Name())
+		return
+	}
+
+	recorder.cache.prune()
+}
+
+// prune removes the least recently used archives beyond maxCachedArchives.
+func (cache inputCache) prune() {
+	paths, err := filepath.Glob(filepath.Join(cache.dir, "*.tgz"))
+	if err != nil || len(paths) <= maxCachedArchives {
+		return
+	}
+
+	modTimes := map[string]time.Time{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		modTimes[path] = info.ModTime()
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return modTimes[paths[i]].After(modTimes[paths[j]])
+	})
+
+	for _, path := range paths[maxCachedArchives:] {
+		os.Remove(path)
+	}
+}
+
+// discard throws the recorded archive away, e.g. when the upload failed.
+func (recorder *cacheRecorder) discard() {
+	recorder.file.Close()
+	os.Remove(recorder.file.Name())
+}
+
+// Fingerprint hashes the names, modes and contents of the given paths under
+// workDir. Unless flat is set, directories are hashed along with everything
+// beneath them. Entries are hashed in the order they're archived, so that the
+// same hash can be taken of what actually went into an archive.
+func Fingerprint(workDir string, paths []string, flat bool) (string, error) {
+	entries, err := listEntries(workDir, paths, flat)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+
+	for _, entry := range entries {
+		path := filepath.Join(workDir, entry)
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		fingerprintEntry(hash, entry, info, link)
+
+		if info.Mode().IsRegular() {
+			file, err := os.Open(path)
+			if err != nil {
+				return "", err
+			}
+
+			_, err = io.Copy(hash, file)
+			file.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fingerprintEntry hashes everything about an entry but a file's contents,
+// which follow it.
+func fingerprintEntry(hash io.Writer, entry string, info os.FileInfo, link string) {
+	fmt.Fprintf(hash, "%s\x00%o\x00", filepath.ToSlash(entry), info.Mode())
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		fmt.Fprintf(hash, "%s\x00", link)
+	}
+}
+
+// listEntries returns every path that archiving the given paths would
+// include, relative to workDir.
+func listEntries(workDir string, paths []string, flat bool) ([]string, error) {
+	if flat {
+		return append([]string{}, paths...), nil
+	}
+
+	entries := []string{}
+
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(workDir, p), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relative, err := filepath.Rel(workDir, path)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, relative)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+)
+
+var _ = Describe("Fingerprint", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-fingerprint")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	fingerprint := func(paths []string, flat bool) string {
+		fp, err := executehelpers.Fingerprint(dir, paths, flat)
+		Expect(err).NotTo(HaveOccurred())
+		return fp
+	}
+
+	It("is stable when nothing changes", func() {
+		Expect(fingerprint([]string{"."}, false)).To(Equal(fingerprint([]string{"."}, false)))
+	})
+
+	It("changes when a file's contents change", func() {
+		before := fingerprint([]string{"."}, false)
+
+		err := ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("B"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fingerprint([]string{"."}, false)).NotTo(Equal(before))
+	})
+
+	It("changes when a file is added", func() {
+		before := fingerprint([]string{"."}, false)
+
+		err := ioutil.WriteFile(filepath.Join(dir, "c.txt"), []byte(""), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fingerprint([]string{"."}, false)).NotTo(Equal(before))
+	})
+
+	It("changes when a file's mode changes", func() {
+		before := fingerprint([]string{"."}, false)
+
+		err := os.Chmod(filepath.Join(dir, "a.txt"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fingerprint([]string{"."}, false)).NotTo(Equal(before))
+	})
+
+	It("only covers the listed paths when flat", func() {
+		before := fingerprint([]string{".", "a.txt"}, true)
+
+		err := ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("B"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fingerprint([]string{".", "a.txt"}, true)).To(Equal(before))
+	})
+})
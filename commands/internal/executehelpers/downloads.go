@@ -8,29 +8,28 @@ import (
 	"github.com/concourse/go-concourse/concourse"
 )
 
-func Download(client concourse.Client, output Output) {
+func Download(client concourse.Client, output Output) error {
 	path := output.Path
 	pipe := output.Pipe
 
 	response, err := client.HTTPClient().Get(pipe.ReadURL)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "download request failed:", err)
+		return fmt.Errorf("download request failed: %s", err)
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		fmt.Fprintln(os.Stderr, badResponseError("downloading bits", response))
-		panic("unexpected-response-code")
+		return badResponseError("downloading bits", response)
 	}
 
 	err = os.MkdirAll(path, 0755)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	err = tarStreamTo(path, response.Body)
-	if err != nil {
-		panic(err)
-	}
+	progress := newTransferProgress("downloading", "downloaded", output.Name, response.ContentLength)
+	defer progress.done()
+
+	return ExtractArchive(path, progress.wrap(response.Body))
 }
@@ -0,0 +1,66 @@
+package executehelpers
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+var SelectFiles = selectFiles
+
+// ArchiveStreamSerialGzip archives just as ArchiveStreamFrom does, but
+// compresses on a single CPU, for comparing against in benchmarks.
+func ArchiveStreamSerialGzip(workDir string, paths []string, flat bool) (io.ReadCloser, error) {
+	return archiveStream(workDir, paths, flat, func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	}, ioutil.Discard, ioutil.Discard)
+}
+
+// ArchiveStreamParallelGzip archives just as ArchiveStreamFrom does, but
+// always compresses in parallel, however many CPUs there are.
+func ArchiveStreamParallelGzip(workDir string, paths []string, flat bool) (io.ReadCloser, error) {
+	return archiveStream(workDir, paths, flat, func(w io.Writer) io.WriteCloser {
+		return newParallelGzipWriter(w)
+	}, ioutil.Discard, ioutil.Discard)
+}
+
+type ProgressBoard struct {
+	board *progressBoard
+}
+
+func NewProgressBoard(out io.Writer, columns int) ProgressBoard {
+	return ProgressBoard{board: &progressBoard{out: out, columns: func() int { return columns }}}
+}
+
+func (board ProgressBoard) Start(verb string, pastVerb string, name string, total int64) TransferProgress {
+	return TransferProgress{progress: newTransferProgressOn(board.board, verb, pastVerb, name, total)}
+}
+
+func (board ProgressBoard) Set(progress TransferProgress, status string) {
+	board.board.set(progress.progress.id, status)
+}
+
+func (board ProgressBoard) Printf(format string, args ...interface{}) {
+	board.board.printf(format, args...)
+}
+
+type TransferProgress struct {
+	progress *transferProgress
+}
+
+func (progress TransferProgress) Transferred(n int64) {
+	progress.progress.transferred = n
+}
+
+func (progress TransferProgress) Status(elapsed time.Duration) string {
+	return progress.progress.status(elapsed)
+}
+
+func (progress TransferProgress) Summary(elapsed time.Duration) string {
+	return progress.progress.summary(elapsed)
+}
+
+func (progress TransferProgress) Done() {
+	progress.progress.done()
+}
@@ -0,0 +1,326 @@
+package executehelpers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UnsafeEntryError is returned when an archive contains an entry that would
+// be written, or point, outside of the directory being extracted into.
+type UnsafeEntryError struct {
+	Name   string
+	Reason string
+}
+
+func (err UnsafeEntryError) Error() string {
+	return fmt.Sprintf("refusing to extract '%s': %s", err.Name, err.Reason)
+}
+
+// ExtractArchive extracts the gzipped tarball read from stream into dir.
+//
+// Unlike the system tar, it refuses entries with absolute paths or '..'
+// components, links whose targets are outside of dir, entries that would be
+// written through a symlink, and device files. Permissions are kept, but
+// setuid, setgid and sticky bits are dropped.
+func ExtractArchive(dir string, stream io.Reader) error {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	gr, err := gzip.NewReader(stream)
+	if err != nil {
+		return fmt.Errorf("could not decompress archive: %s", err)
+	}
+
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+
+	dirs := []*tar.Header{}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not read archive: %s", err)
+		}
+
+		err = extractEntry(root, hdr, tr)
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag == tar.TypeDir {
+			dirs = append(dirs, hdr)
+		}
+	}
+
+	// directories are given their modes only once everything is written, so
+	// that a read-only directory's contents can still be extracted into it;
+	// the deepest go first, so that their parents remain searchable
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err := finishDirectory(root, dirs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func finishDirectory(root string, hdr *tar.Header) error {
+	relative, err := safeRelativePath(hdr.Name)
+	if err != nil || relative == "." {
+		return nil
+	}
+
+	path := filepath.Join(root, relative)
+
+	err = os.Chmod(path, os.FileMode(hdr.Mode).Perm())
+	if err != nil {
+		return fmt.Errorf("could not extract '%s': %s", hdr.Name, err)
+	}
+
+	os.Chtimes(path, hdr.ModTime, hdr.ModTime)
+
+	return nil
+}
+
+func extractEntry(root string, hdr *tar.Header, contents io.Reader) error {
+	relative, err := safeRelativePath(hdr.Name)
+	if err != nil {
+		return UnsafeEntryError{Name: hdr.Name, Reason: err.Error()}
+	}
+
+	if relative == "." {
+		// the archive's root; the directory already exists
+		return nil
+	}
+
+	path := filepath.Join(root, relative)
+
+	err = checkNoSymlinkParents(root, relative)
+	if err != nil {
+		return UnsafeEntryError{Name: hdr.Name, Reason: err.Error()}
+	}
+
+	mode := os.FileMode(hdr.Mode).Perm()
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if info, statErr := os.Lstat(path); statErr == nil && info.Mode()&os.ModeSymlink != 0 {
+			return UnsafeEntryError{Name: hdr.Name, Reason: "directory would replace a symlink"}
+		}
+
+		// its mode is applied by finishDirectory
+		err = os.MkdirAll(path, 0755)
+
+	case tar.TypeReg, tar.TypeRegA:
+		err = writeFile(path, mode, contents)
+
+	case tar.TypeSymlink:
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return fmt.Errorf("could not extract '%s': %s", hdr.Name, err)
+		}
+
+		if !linkStaysWithin(root, filepath.Dir(path), hdr.Linkname) {
+			return UnsafeEntryError{Name: hdr.Name, Reason: "symlink points outside of the output"}
+		}
+
+		err = replaceWith(path, func() error {
+			return os.Symlink(hdr.Linkname, path)
+		})
+
+	case tar.TypeLink:
+		target, linkErr := safeRelativePath(hdr.Linkname)
+		if linkErr != nil {
+			return UnsafeEntryError{Name: hdr.Name, Reason: "hard link " + linkErr.Error()}
+		}
+
+		linkErr = checkNoSymlinkParents(root, target)
+		if linkErr != nil {
+			return UnsafeEntryError{Name: hdr.Name, Reason: "hard link " + linkErr.Error()}
+		}
+
+		targetInfo, linkErr := os.Lstat(filepath.Join(root, target))
+		if linkErr != nil || !targetInfo.Mode().IsRegular() {
+			return UnsafeEntryError{Name: hdr.Name, Reason: "hard link must point to a file earlier in the archive"}
+		}
+
+		err = replaceWith(path, func() error {
+			return os.Link(filepath.Join(root, target), path)
+		})
+
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		return UnsafeEntryError{Name: hdr.Name, Reason: "device and fifo entries are not allowed"}
+
+	default:
+		// e.g. pax or GNU metadata entries; nothing to write
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not extract '%s': %s", hdr.Name, err)
+	}
+
+	if hdr.Typeflag != tar.TypeSymlink && hdr.Typeflag != tar.TypeDir {
+		os.Chtimes(path, hdr.ModTime, hdr.ModTime)
+	}
+
+	return nil
+}
+
+// safeRelativePath cleans an entry name, and rejects it if it's absolute or
+// climbs out of the directory it's relative to.
+func safeRelativePath(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty path")
+	}
+
+	slashed := strings.Replace(name, `\`, "/", -1)
+
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("path is absolute")
+	}
+
+	for _, component := range strings.Split(slashed, "/") {
+		if component == ".." {
+			return "", fmt.Errorf("path contains '..'")
+		}
+	}
+
+	return filepath.Clean(filepath.FromSlash(slashed)), nil
+}
+
+// checkNoSymlinkParents makes sure none of the directories leading to the
+// entry are symlinks, which an earlier entry could have planted to redirect
+// writes outside of the output.
+func checkNoSymlinkParents(root string, relative string) error {
+	parent := filepath.Dir(relative)
+	if parent == "." {
+		return nil
+	}
+
+	current := root
+	for _, component := range strings.Split(parent, string(filepath.Separator)) {
+		current = filepath.Join(current, component)
+
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("path goes through a symlink")
+		}
+	}
+
+	return nil
+}
+
+// linkStaysWithin returns true if a symlink in dir pointing to target would
+// resolve to somewhere inside root. The target is followed through what has
+// been extracted so far: it may only go through a symlink as its last
+// component, whose own target was checked when it was extracted, and may only
+// climb out of directories that exist, so that later entries can't change
+// where it leads.
+func linkStaysWithin(root string, dir string, target string) bool {
+	if target == "" || filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		return false
+	}
+
+	components := strings.Split(filepath.ToSlash(target), "/")
+
+	current := dir
+	for i, component := range components {
+		switch component {
+		case "", ".":
+			continue
+
+		case "..":
+			info, err := os.Lstat(current)
+			if err != nil || !info.IsDir() {
+				return false
+			}
+
+			current = filepath.Dir(current)
+
+		default:
+			current = filepath.Join(current, component)
+
+			if i < len(components)-1 {
+				info, err := os.Lstat(current)
+				if err == nil && info.Mode()&os.ModeSymlink != 0 {
+					return false
+				}
+			}
+		}
+
+		if current != root && !strings.HasPrefix(current, root+string(filepath.Separator)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func writeFile(path string, mode os.FileMode, contents io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	return replaceWith(path, func() error {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(file, contents)
+
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// the umask may have masked some bits out
+		return os.Chmod(path, mode)
+	})
+}
+
+// replaceWith removes whatever non-directory is at path before creating the
+// new entry, so that an existing symlink is replaced rather than followed.
+func replaceWith(path string, create func() error) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(path)
+	if err == nil && !info.IsDir() {
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return create()
+}
@@ -0,0 +1,262 @@
+package executehelpers_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+)
+
+type archiveEntry struct {
+	header   tar.Header
+	contents string
+}
+
+func buildArchive(entries ...archiveEntry) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	for _, entry := range entries {
+		hdr := entry.header
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(entry.contents))
+		}
+
+		if hdr.Mode == 0 {
+			hdr.Mode = 0644
+		}
+
+		Expect(tw.WriteHeader(&hdr)).To(Succeed())
+
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(entry.contents))
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+
+	Expect(tw.Close()).To(Succeed())
+	Expect(gw.Close()).To(Succeed())
+
+	return buf
+}
+
+func tarFile(name string, contents string) archiveEntry {
+	return archiveEntry{
+		header:   tar.Header{Name: name, Typeflag: tar.TypeReg},
+		contents: contents,
+	}
+}
+
+func tarDir(name string) archiveEntry {
+	return archiveEntry{
+		header: tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755},
+	}
+}
+
+func tarSymlink(name string, target string) archiveEntry {
+	return archiveEntry{
+		header: tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target, Mode: 0777},
+	}
+}
+
+func tarHardLink(name string, target string) archiveEntry {
+	return archiveEntry{
+		header: tar.Header{Name: name, Typeflag: tar.TypeLink, Linkname: target},
+	}
+}
+
+var _ = Describe("ExtractArchive", func() {
+	var parent string
+	var outputDir string
+
+	BeforeEach(func() {
+		var err error
+		parent, err = ioutil.TempDir("", "fly-extract")
+		Expect(err).NotTo(HaveOccurred())
+
+		outputDir = filepath.Join(parent, "output")
+
+		err = os.Mkdir(outputDir, 0755)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(parent)
+	})
+
+	extract := func(entries ...archiveEntry) error {
+		return executehelpers.ExtractArchive(outputDir, buildArchive(entries...))
+	}
+
+	It("extracts files, directories and links", func() {
+		executable := tarFile("bin/run", "#!/bin/sh\n")
+		executable.header.Mode = 04755
+
+		err := extract(
+			tarDir("./"),
+			tarDir("bin/"),
+			executable,
+			tarFile("data/values.txt", "some values"),
+			tarSymlink("run", "bin/run"),
+			tarHardLink("values-again.txt", "data/values.txt"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "data", "values.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some values"))
+
+		info, err := os.Stat(filepath.Join(outputDir, "bin", "run"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode()).To(Equal(os.FileMode(0755)), "setuid should be dropped")
+
+		target, err := os.Readlink(filepath.Join(outputDir, "run"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target).To(Equal("bin/run"))
+
+		contents, err = ioutil.ReadFile(filepath.Join(outputDir, "values-again.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some values"))
+	})
+
+	It("replaces existing files rather than writing through them", func() {
+		err := ioutil.WriteFile(filepath.Join(outputDir, "existing"), []byte("old"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = extract(tarFile("existing", "new"))
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "existing"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("new"))
+	})
+
+	It("gives directories their modes once their contents are written", func() {
+		readOnly := tarDir("read-only/")
+		readOnly.header.Mode = 0555
+
+		err := extract(
+			readOnly,
+			tarFile("read-only/file", "some contents"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		defer os.Chmod(filepath.Join(outputDir, "read-only"), 0755)
+
+		info, err := os.Stat(filepath.Join(outputDir, "read-only"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0555)))
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "read-only", "file"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some contents"))
+	})
+
+	It("allows symlinks that climb out of directories within the output", func() {
+		err := extract(
+			tarDir("sub/"),
+			tarFile("data", "some data"),
+			tarSymlink("link", "sub/../data"),
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(filepath.Join(outputDir, "link"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("some data"))
+	})
+
+	Context("with a malicious archive", func() {
+		itRefuses := func(reason string, entries ...archiveEntry) {
+			It("refuses to extract it", func() {
+				err := extract(entries...)
+				Expect(err).To(BeAssignableToTypeOf(executehelpers.UnsafeEntryError{}))
+				Expect(err.Error()).To(ContainSubstring(reason))
+			})
+
+			It("does not write outside of the output directory", func() {
+				extract(entries...)
+
+				siblings, err := ioutil.ReadDir(parent)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(siblings).To(HaveLen(1))
+				Expect(siblings[0].Name()).To(Equal("output"))
+			})
+		}
+
+		Context("with an absolute path", func() {
+			itRefuses("path is absolute", tarFile("/tmp/escaped", "pwned"))
+		})
+
+		Context("with a '..' path", func() {
+			itRefuses("path contains '..'", tarFile("../escaped", "pwned"))
+		})
+
+		Context("with a '..' path that cleans to inside the output", func() {
+			itRefuses("path contains '..'", tarFile("sub/../../output/escaped", "pwned"))
+		})
+
+		Context("with a symlink pointing outside", func() {
+			itRefuses("symlink points outside of the output", tarSymlink("escape", "../"))
+		})
+
+		Context("with an absolute symlink", func() {
+			itRefuses("symlink points outside of the output", tarSymlink("escape", "/etc/passwd"))
+		})
+
+		Context("with a symlink that climbs out from a subdirectory", func() {
+			itRefuses("symlink points outside of the output", tarDir("a/"), tarSymlink("a/escape", "../../"))
+		})
+
+		Context("with a symlink that climbs out through another symlink", func() {
+			itRefuses("symlink points outside of the output", tarSymlink("b", "."), tarSymlink("a", "b/../"))
+		})
+
+		Context("with a symlink that climbs out of a path a later symlink could fill in", func() {
+			itRefuses("symlink points outside of the output", tarSymlink("a", "x/../y"), tarSymlink("x", "."))
+		})
+
+		Context("with a file written through a planted symlink", func() {
+			BeforeEach(func() {
+				err := os.Symlink(parent, filepath.Join(outputDir, "planted"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			itRefuses("path goes through a symlink", tarFile("planted/escaped", "pwned"))
+		})
+
+		Context("with a directory replacing a planted symlink", func() {
+			BeforeEach(func() {
+				err := os.Symlink(parent, filepath.Join(outputDir, "planted"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			itRefuses("directory would replace a symlink", tarDir("planted/"))
+		})
+
+		Context("with a hard link pointing outside", func() {
+			itRefuses("hard link path contains '..'", tarHardLink("escape", "../../etc/passwd"))
+		})
+
+		Context("with a device file", func() {
+			itRefuses("device and fifo entries are not allowed", archiveEntry{
+				header: tar.Header{Name: "null", Typeflag: tar.TypeChar, Devmajor: 1, Devminor: 3},
+			})
+		})
+	})
+
+	Context("when the stream is not a gzipped tarball", func() {
+		It("returns an error", func() {
+			err := executehelpers.ExtractArchive(outputDir, bytes.NewBufferString("not an archive"))
+			Expect(err).To(MatchError(ContainSubstring("could not decompress archive")))
+		})
+	})
+})
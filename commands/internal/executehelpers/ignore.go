@@ -0,0 +1,240 @@
+package executehelpers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the file, in the root of an input, listing paths to leave
+// out of the upload. It uses the same syntax as .gitignore.
+const IgnoreFileName = ".flyignore"
+
+type excludePattern struct {
+	regexp  *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Excluder decides which paths of an input are left out of its upload, based
+// on the input's .flyignore and any patterns given on the command line.
+type Excluder struct {
+	patterns []excludePattern
+}
+
+// LoadExcluder reads the .flyignore in dir, if there is one, and combines it
+// with the extra patterns. The extra patterns come last, so they take
+// precedence over the file.
+func LoadExcluder(dir string, extra []string) (Excluder, error) {
+	var lines []string
+
+	ignoreFile, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if err == nil {
+		defer ignoreFile.Close()
+
+		scanner := bufio.NewScanner(ignoreFile)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		err = scanner.Err()
+		if err != nil {
+			return Excluder{}, fmt.Errorf("could not read %s: %s", IgnoreFileName, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return Excluder{}, fmt.Errorf("could not read %s: %s", IgnoreFileName, err)
+	}
+
+	excluder := Excluder{}
+
+	for _, line := range append(lines, extra...) {
+		pattern, ok, err := parseExcludePattern(line)
+		if err != nil {
+			return Excluder{}, err
+		}
+
+		if ok {
+			excluder.patterns = append(excluder.patterns, pattern)
+		}
+	}
+
+	return excluder, nil
+}
+
+// Empty returns true when no path would ever be excluded.
+func (excluder Excluder) Empty() bool {
+	return len(excluder.patterns) == 0
+}
+
+// Excludes returns true when the given path, relative to the input and
+// separated by slashes, should be left out. As with .gitignore, the last
+// matching pattern wins, and a path within an excluded directory is excluded
+// too; the directories are checked here because paths may be given without
+// walking down to them, as with the files listed by git.
+func (excluder Excluder) Excludes(path string, isDir bool) bool {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && excluder.matches(path[:i], true) {
+			return true
+		}
+	}
+
+	return excluder.matches(path, isDir)
+}
+
+func (excluder Excluder) matches(path string, isDir bool) bool {
+	excluded := false
+
+	for _, pattern := range excluder.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+
+		if pattern.regexp.MatchString(path) {
+			excluded = !pattern.negate
+		}
+	}
+
+	return excluded
+}
+
+func parseExcludePattern(line string) (excludePattern, bool, error) {
+	source := line
+
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return excludePattern{}, false, nil
+	}
+
+	pattern := excludePattern{}
+
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// a pattern with a slash anywhere but the end is relative to the root of
+	// the input; otherwise it may match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	if line == "" {
+		return excludePattern{}, false, nil
+	}
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(.*/)?" + expr + "$"
+	}
+
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return excludePattern{}, false, fmt.Errorf("invalid exclude pattern '%s': %s", source, err)
+	}
+
+	pattern.regexp = compiled
+
+	return pattern, true, nil
+}
+
+func globToRegexp(glob string) string {
+	var expr bytes.Buffer
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				expr.WriteString("(.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				expr.WriteString(`\[`)
+				continue
+			}
+
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return expr.String()
+}
+
+// filterPaths walks each of the given paths under workDir and returns every
+// file and directory the excluder keeps, relative to workDir. Excluded
+// directories are not descended into.
+func filterPaths(workDir string, paths []string, excluder Excluder) ([]string, error) {
+	filtered := []string{}
+
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(workDir, p), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relative, err := filepath.Rel(workDir, path)
+			if err != nil {
+				return err
+			}
+
+			if relative == "." {
+				filtered = append(filtered, relative)
+				return nil
+			}
+
+			if excluder.Excludes(relative, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			filtered = append(filtered, relative)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,164 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+)
+
+// git runs git in dir, as someone with an identity to commit with.
+func git(dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(
+		os.Environ(),
+		"GIT_AUTHOR_NAME=fly",
+		"GIT_AUTHOR_EMAIL=fly@example.com",
+		"GIT_COMMITTER_NAME=fly",
+		"GIT_COMMITTER_EMAIL=fly@example.com",
+	)
+
+	out, err := cmd.CombinedOutput()
+	Expect(err).NotTo(HaveOccurred(), string(out))
+}
+
+var _ = Describe("Excluder", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-excluder")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	load := func(extra ...string) executehelpers.Excluder {
+		excluder, err := executehelpers.LoadExcluder(dir, extra)
+		Expect(err).NotTo(HaveOccurred())
+		return excluder
+	}
+
+	Context("when there is no .flyignore and no patterns", func() {
+		It("excludes nothing", func() {
+			excluder := load()
+			Expect(excluder.Empty()).To(BeTrue())
+			Expect(excluder.Excludes("anything", false)).To(BeFalse())
+		})
+	})
+
+	Context("when there is a .flyignore", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(dir, ".flyignore"), []byte(`
+# comments and blank lines are skipped
+
+*.log
+!important.log
+/tmp
+node_modules/
+docs/**/*.pdf
+\#literal
+`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("matches unanchored patterns at any depth", func() {
+			excluder := load()
+			Expect(excluder.Excludes("debug.log", false)).To(BeTrue())
+			Expect(excluder.Excludes("a/b/debug.log", false)).To(BeTrue())
+			Expect(excluder.Excludes("debug.log.txt", false)).To(BeFalse())
+		})
+
+		It("lets later negated patterns re-include paths", func() {
+			excluder := load()
+			Expect(excluder.Excludes("important.log", false)).To(BeFalse())
+			Expect(excluder.Excludes("sub/important.log", false)).To(BeFalse())
+		})
+
+		It("anchors patterns with a leading slash to the input root", func() {
+			excluder := load()
+			Expect(excluder.Excludes("tmp", true)).To(BeTrue())
+			Expect(excluder.Excludes("src/tmp", true)).To(BeFalse())
+		})
+
+		It("only matches directories with patterns ending in a slash", func() {
+			excluder := load()
+			Expect(excluder.Excludes("node_modules", true)).To(BeTrue())
+			Expect(excluder.Excludes("web/node_modules", true)).To(BeTrue())
+			Expect(excluder.Excludes("node_modules", false)).To(BeFalse())
+		})
+
+		It("matches any number of directories with **", func() {
+			excluder := load()
+			Expect(excluder.Excludes("docs/manual.pdf", false)).To(BeTrue())
+			Expect(excluder.Excludes("docs/a/b/manual.pdf", false)).To(BeTrue())
+			Expect(excluder.Excludes("other/manual.pdf", false)).To(BeFalse())
+		})
+
+		It("treats escaped characters literally", func() {
+			excluder := load()
+			Expect(excluder.Excludes("#literal", false)).To(BeTrue())
+		})
+
+		It("excludes everything within an excluded directory", func() {
+			excluder := load()
+			Expect(excluder.Excludes("node_modules/pkg/index.js", false)).To(BeTrue())
+			Expect(excluder.Excludes("web/node_modules/index.js", false)).To(BeTrue())
+			Expect(excluder.Excludes("tmp/scratch.txt", false)).To(BeTrue())
+			Expect(excluder.Excludes("src/tmp/scratch.txt", false)).To(BeFalse())
+			Expect(excluder.Excludes("node_modules.txt", false)).To(BeFalse())
+		})
+
+		It("does not re-include paths within an excluded directory", func() {
+			excluder := load("!tmp/important.log")
+			Expect(excluder.Excludes("tmp/important.log", false)).To(BeTrue())
+		})
+
+		It("applies extra patterns after the file", func() {
+			excluder := load("*.txt", "important.log")
+			Expect(excluder.Excludes("notes.txt", false)).To(BeTrue())
+			Expect(excluder.Excludes("important.log", false)).To(BeTrue())
+		})
+	})
+
+	Context("when only the files known to git are selected", func() {
+		BeforeEach(func() {
+			for _, file := range []string{"main.go", "node_modules/pkg/index.js", "build/app", "web/build/app"} {
+				err := os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(dir, file), []byte("content"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err := ioutil.WriteFile(filepath.Join(dir, ".flyignore"), []byte("node_modules/\n/build\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			git(dir, "init", "-q")
+			git(dir, "add", "main.go", "node_modules")
+			git(dir, "commit", "-q", "-m", "initial")
+		})
+
+		It("leaves out the files within excluded directories", func() {
+			files, flat, err := executehelpers.SelectFiles(dir, true, []string{"web/"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flat).To(BeTrue())
+			Expect(files).To(ConsistOf(".flyignore", "main.go"))
+		})
+	})
+
+	Context("when a pattern is invalid", func() {
+		It("returns an error", func() {
+			_, err := executehelpers.LoadExcluder(dir, []string{"[z-a]"})
+			Expect(err).To(MatchError(ContainSubstring("invalid exclude pattern '[z-a]'")))
+		})
+	})
+})
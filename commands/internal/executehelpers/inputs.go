@@ -25,12 +25,26 @@ func DetermineInputs(
 	taskInputs []atc.TaskInputConfig,
 	inputMappings []flaghelpers.InputPairFlag,
 	inputsFrom flaghelpers.JobFlag,
+	inputsFromBuild flaghelpers.JobBuildFlag,
+	inputVersions []flaghelpers.InputVersionFlag,
+	jobInputMapping map[string]string,
 ) ([]Input, error) {
 	err := CheckForUnknownInputMappings(inputMappings, taskInputs)
 	if err != nil {
 		return nil, err
 	}
 
+	if inputsFromBuild.PipelineName != "" {
+		if inputsFrom.PipelineName != "" && (inputsFrom.PipelineName != inputsFromBuild.PipelineName || inputsFrom.JobName != inputsFromBuild.JobName) {
+			return nil, errors.New("--inputs-from and --inputs-from-build must refer to the same job")
+		}
+
+		inputsFrom = flaghelpers.JobFlag{
+			PipelineName: inputsFromBuild.PipelineName,
+			JobName:      inputsFromBuild.JobName,
+		}
+	}
+
 	if len(inputMappings) == 0 && inputsFrom.PipelineName == "" && inputsFrom.JobName == "" {
 		wd, err := os.Getwd()
 		if err != nil {
@@ -48,7 +62,17 @@ func DetermineInputs(
 		return nil, err
 	}
 
-	inputsFromJob, err := FetchInputsFromJob(client, inputsFrom)
+	var inputsFromJob map[string]Input
+	if inputsFromBuild.PipelineName != "" {
+		inputsFromJob, err = FetchInputsFromBuild(client, inputsFromBuild)
+	} else {
+		inputsFromJob, err = FetchInputsFromJob(client, inputsFrom)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = PinInputVersions(inputsFromJob, inputVersions)
 	if err != nil {
 		return nil, err
 	}
@@ -57,10 +81,13 @@ func DetermineInputs(
 	for _, taskInput := range taskInputs {
 		input, found := inputsFromLocal[taskInput.Name]
 		if !found {
-			input, found = inputsFromJob[taskInput.Name]
+			input, found = inputsFromJob[jobInputName(taskInput.Name, jobInputMapping)]
 			if !found {
 				return nil, fmt.Errorf("missing required input `%s`", taskInput.Name)
 			}
+
+			// the task sees the input under its own name for it
+			input.Name = taskInput.Name
 		}
 
 		inputs = append(inputs, input)
@@ -133,3 +160,98 @@ func FetchInputsFromJob(client concourse.Client, inputsFrom flaghelpers.JobFlag)
 
 	return kvMap, nil
 }
+
+// FetchInputsFromBuild returns the inputs that the given build of a job
+// used, at the versions it used. Their sources are those of the pipeline's
+// resources, and their params and tags those of the job's get steps.
+func FetchInputsFromBuild(client concourse.Client, jobBuild flaghelpers.JobBuildFlag) (map[string]Input, error) {
+	build, found, err := client.JobBuild(jobBuild.PipelineName, jobBuild.JobName, jobBuild.BuildName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("build not found")
+	}
+
+	resources, found, err := client.BuildResources(build.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("build resources not found")
+	}
+
+	pipelineConfig, _, found, err := client.PipelineConfig(jobBuild.PipelineName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("pipeline not found")
+	}
+
+	var job atc.JobConfig
+	for _, jobConfig := range pipelineConfig.Jobs {
+		if jobConfig.Name == jobBuild.JobName {
+			job = jobConfig
+			break
+		}
+	}
+
+	kvMap := map[string]Input{}
+
+	for _, buildInput := range resources.Inputs {
+		var resource atc.ResourceConfig
+		found := false
+
+		for _, resourceConfig := range pipelineConfig.Resources {
+			if resourceConfig.Name == buildInput.Resource {
+				resource = resourceConfig
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("resource `%s` of input `%s` is no longer in the pipeline", buildInput.Resource, buildInput.Name)
+		}
+
+		// the get step may have been changed or removed since the build ran,
+		// in which case the input is fetched without params or tags
+		step, _ := findPlanStep(job.Plan, func(step atc.PlanConfig) bool {
+			return step.Get == buildInput.Name
+		})
+
+		kvMap[buildInput.Name] = Input{
+			Name: buildInput.Name,
+			BuildInput: atc.BuildInput{
+				Name:     buildInput.Name,
+				Resource: buildInput.Resource,
+				Type:     buildInput.Type,
+				Source:   resource.Source,
+				Version:  buildInput.Version,
+				Params:   step.Params,
+				Tags:     step.Tags,
+			},
+		}
+	}
+
+	return kvMap, nil
+}
+
+// PinInputVersions sets the versions of individual inputs taken from a job.
+func PinInputVersions(inputs map[string]Input, inputVersions []flaghelpers.InputVersionFlag) error {
+	for _, inputVersion := range inputVersions {
+		input, found := inputs[inputVersion.Name]
+		if !found {
+			return fmt.Errorf("cannot pin the version of input `%s`; it is not one of the job's inputs", inputVersion.Name)
+		}
+
+		input.BuildInput.Version = inputVersion.Version
+		inputs[inputVersion.Name] = input
+	}
+
+	return nil
+}
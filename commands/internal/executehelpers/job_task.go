@@ -0,0 +1,173 @@
+package executehelpers
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/config"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+// LoadJobTaskConfig returns the config of a task step in a pipeline's job,
+// along with whether the step is privileged and its input_mapping, from the
+// names of the task's inputs to those of the job's. A config loaded from a
+// 'file' is read from the local path given for its input, since fly has no
+// way to fetch it from the input's resource.
+func LoadJobTaskConfig(
+	client concourse.Client,
+	jobTask flaghelpers.JobTaskFlag,
+	inputMappings []flaghelpers.InputPairFlag,
+	args []string,
+) (atc.TaskConfig, bool, map[string]string, error) {
+	pipelineConfig, _, found, err := client.PipelineConfig(jobTask.PipelineName)
+	if err != nil {
+		return atc.TaskConfig{}, false, nil, err
+	}
+
+	if !found {
+		return atc.TaskConfig{}, false, nil, errors.New("pipeline not found")
+	}
+
+	var job atc.JobConfig
+	found = false
+
+	for _, jobConfig := range pipelineConfig.Jobs {
+		if jobConfig.Name == jobTask.JobName {
+			job = jobConfig
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return atc.TaskConfig{}, false, nil, fmt.Errorf("job '%s' not found in pipeline '%s'", jobTask.JobName, jobTask.PipelineName)
+	}
+
+	step, found := findTaskStep(job.Plan, jobTask.StepName)
+	if !found {
+		return atc.TaskConfig{}, false, nil, fmt.Errorf("task '%s' not found in job '%s'", jobTask.StepName, jobTask.JobName)
+	}
+
+	var taskConfig atc.TaskConfig
+
+	if step.TaskConfigPath != "" {
+		taskConfig, err = loadTaskConfigFromInput(step.TaskConfigPath, step.InputMapping, inputMappings)
+		if err != nil {
+			return atc.TaskConfig{}, false, nil, err
+		}
+	} else if step.TaskConfig != nil {
+		// a config from a file is validated as it's loaded
+		err = step.TaskConfig.Validate()
+		if err != nil {
+			return atc.TaskConfig{}, false, nil, fmt.Errorf("invalid config for task '%s': %s", jobTask.StepName, err)
+		}
+
+		taskConfig = *step.TaskConfig
+	} else {
+		return atc.TaskConfig{}, false, nil, fmt.Errorf("task '%s' has neither a config nor a file", jobTask.StepName)
+	}
+
+	if len(step.Params) > 0 && taskConfig.Params == nil {
+		taskConfig.Params = map[string]string{}
+	}
+
+	// params on the step take precedence over those in the config
+	for name, value := range step.Params {
+		taskConfig.Params[name] = fmt.Sprintf("%v", value)
+	}
+
+	return config.OverrideTaskConfig(taskConfig, args), step.Privileged, step.InputMapping, nil
+}
+
+// loadTaskConfigFromInput reads a task config whose path starts with the name
+// of the job's input it comes from, e.g. 'some-repo/ci/task.yml', from the
+// local path given for that input, or for the task input mapped to it.
+func loadTaskConfigFromInput(
+	configPath string,
+	jobInputMapping map[string]string,
+	inputMappings []flaghelpers.InputPairFlag,
+) (atc.TaskConfig, error) {
+	segments := strings.SplitN(configPath, "/", 2)
+	if len(segments) != 2 {
+		return atc.TaskConfig{}, fmt.Errorf("invalid task config path '%s'", configPath)
+	}
+
+	inputName, pathInInput := segments[0], segments[1]
+
+	for _, mapping := range inputMappings {
+		if jobInputName(mapping.Name, jobInputMapping) == inputName {
+			return config.LoadTaskConfig(filepath.Join(mapping.Path, filepath.FromSlash(pathInInput)), nil)
+		}
+	}
+
+	// the input is given locally under the task's name for it
+	localName := inputName
+	for taskInputName, name := range jobInputMapping {
+		if name == inputName && (localName == inputName || taskInputName < localName) {
+			localName = taskInputName
+		}
+	}
+
+	return atc.TaskConfig{}, fmt.Errorf(
+		"the task config is loaded from '%s'; provide the '%s' input locally with -i %s=PATH",
+		configPath,
+		localName,
+		localName,
+	)
+}
+
+// jobInputName returns the name of the job's input that a task's input is
+// mapped to, which is the same name unless the step maps it to another.
+func jobInputName(taskInputName string, jobInputMapping map[string]string) string {
+	if name, found := jobInputMapping[taskInputName]; found {
+		return name
+	}
+
+	return taskInputName
+}
+
+func findTaskStep(plan atc.PlanSequence, name string) (atc.PlanConfig, bool) {
+	return findPlanStep(plan, func(step atc.PlanConfig) bool {
+		return step.Task == name
+	})
+}
+
+// findPlanStep returns the first step in the plan, including those nested in
+// aggregates, dos and hooks, that matches.
+func findPlanStep(plan atc.PlanSequence, matches func(atc.PlanConfig) bool) (atc.PlanConfig, bool) {
+	for _, step := range plan {
+		if found, ok := findPlanStepIn(step, matches); ok {
+			return found, true
+		}
+	}
+
+	return atc.PlanConfig{}, false
+}
+
+func findPlanStepIn(step atc.PlanConfig, matches func(atc.PlanConfig) bool) (atc.PlanConfig, bool) {
+	if matches(step) {
+		return step, true
+	}
+
+	for _, sequence := range []*atc.PlanSequence{step.Aggregate, step.Do} {
+		if sequence != nil {
+			if found, ok := findPlanStep(*sequence, matches); ok {
+				return found, true
+			}
+		}
+	}
+
+	for _, hook := range []*atc.PlanConfig{step.Try, step.Success, step.Failure, step.Ensure} {
+		if hook != nil {
+			if found, ok := findPlanStepIn(*hook, matches); ok {
+				return found, true
+			}
+		}
+	}
+
+	return atc.PlanConfig{}, false
+}
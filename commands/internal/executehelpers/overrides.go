@@ -0,0 +1,238 @@
+package executehelpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+)
+
+// OverrideParams sets the task's params from a YAML file of names to values,
+// and then from individual name=value pairs, so that the pairs win. Only
+// params the task already declares may be set.
+func OverrideParams(
+	config atc.TaskConfig,
+	paramsFrom flaghelpers.PathFlag,
+	params []flaghelpers.VariablePairFlag,
+) (atc.TaskConfig, error) {
+	overrides := []flaghelpers.VariablePairFlag{}
+
+	if paramsFrom != "" {
+		fromFile, err := loadParamsFile(string(paramsFrom))
+		if err != nil {
+			return atc.TaskConfig{}, err
+		}
+
+		overrides = append(overrides, fromFile...)
+	}
+
+	overrides = append(overrides, params...)
+
+	err := CheckForUnknownParams(overrides, config.Params)
+	if err != nil {
+		return atc.TaskConfig{}, err
+	}
+
+	if len(overrides) == 0 {
+		return config, nil
+	}
+
+	// don't modify the map of whoever gave us the config
+	newParams := make(map[string]string, len(config.Params))
+	for name, value := range config.Params {
+		newParams[name] = value
+	}
+
+	for _, override := range overrides {
+		newParams[override.Name] = override.Value
+	}
+
+	config.Params = newParams
+
+	return config, nil
+}
+
+// CheckForUnknownParams returns an error naming, sorted, every param that
+// the task does not declare.
+func CheckForUnknownParams(params []flaghelpers.VariablePairFlag, validParams map[string]string) error {
+	unknown := map[string]bool{}
+	for _, param := range params {
+		if _, found := validParams[param.Name]; !found {
+			unknown[param.Name] = true
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	names := []string{}
+	for name := range unknown {
+		names = append(names, "`"+name+"`")
+	}
+
+	sort.Strings(names)
+
+	if len(names) == 1 {
+		return fmt.Errorf("unknown param %s", names[0])
+	}
+
+	return fmt.Errorf("unknown params %s", strings.Join(names, ", "))
+}
+
+func loadParamsFile(path string) ([]flaghelpers.VariablePairFlag, error) {
+	payload, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read params file: %s", err)
+	}
+
+	var values map[string]interface{}
+	err = yaml.Unmarshal(payload, &values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse params file: %s", err)
+	}
+
+	pairs := []flaghelpers.VariablePairFlag{}
+	for name, value := range values {
+		text, err := paramText(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for param `%s`: %s", name, err)
+		}
+
+		pairs = append(pairs, flaghelpers.VariablePairFlag{
+			Name:  name,
+			Value: text,
+		})
+	}
+
+	return pairs, nil
+}
+
+// paramText returns the text a param is set to: scalars as they're written,
+// and lists and objects as JSON, which is how tasks can read them back.
+func paramText(value interface{}) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+	case map[interface{}]interface{}, []interface{}:
+		sanitized, err := sanitizeYAMLValue(value)
+		if err != nil {
+			return "", err
+		}
+
+		payload, err := json.Marshal(sanitized)
+		if err != nil {
+			return "", err
+		}
+
+		return string(payload), nil
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+// OverrideImage replaces the task's image, either with an image URL or with
+// an image resource described by a YAML file with a type and a source.
+func OverrideImage(config atc.TaskConfig, image string, imageResource flaghelpers.PathFlag) (atc.TaskConfig, error) {
+	if image != "" && imageResource != "" {
+		return atc.TaskConfig{}, errors.New("only one of --image and --image-resource can be given")
+	}
+
+	if image != "" {
+		config.Image = image
+		config.ImageResource = nil
+	}
+
+	if imageResource != "" {
+		payload, err := ioutil.ReadFile(string(imageResource))
+		if err != nil {
+			return atc.TaskConfig{}, fmt.Errorf("failed to read image resource: %s", err)
+		}
+
+		var resource atc.ImageResource
+		err = yaml.Unmarshal(payload, &resource)
+		if err != nil {
+			return atc.TaskConfig{}, fmt.Errorf("failed to parse image resource: %s", err)
+		}
+
+		if resource.Type == "" {
+			return atc.TaskConfig{}, errors.New("image resource must specify a type")
+		}
+
+		// the plan is sent as JSON, which can't represent the maps that YAML
+		// decodes nested objects into
+		source, err := sanitizeSource(resource.Source)
+		if err != nil {
+			return atc.TaskConfig{}, fmt.Errorf("invalid image resource source: %s", err)
+		}
+
+		resource.Source = source
+
+		config.Image = ""
+		config.ImageResource = &resource
+	}
+
+	return config, nil
+}
+
+func sanitizeSource(source atc.Source) (atc.Source, error) {
+	sanitized := atc.Source{}
+
+	for key, value := range source {
+		clean, err := sanitizeYAMLValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		sanitized[key] = clean
+	}
+
+	return sanitized, nil
+}
+
+func sanitizeYAMLValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		sanitized := map[string]interface{}{}
+
+		for key, val := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("non-string key: %v", key)
+			}
+
+			clean, err := sanitizeYAMLValue(val)
+			if err != nil {
+				return nil, err
+			}
+
+			sanitized[name] = clean
+		}
+
+		return sanitized, nil
+
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+
+		for i, val := range v {
+			clean, err := sanitizeYAMLValue(val)
+			if err != nil {
+				return nil, err
+			}
+
+			sanitized[i] = clean
+		}
+
+		return sanitized, nil
+
+	default:
+		return value, nil
+	}
+}
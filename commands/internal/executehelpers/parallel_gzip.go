@@ -0,0 +1,138 @@
+package executehelpers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// compressionBlockSize is the amount of data each parallel gzip worker
+// compresses at a time.
+const compressionBlockSize = 1 << 20
+
+// gzipWriters holds compressors between blocks, as each allocates far more
+// than a block's worth of state.
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// parallelGzipWriter compresses blocks of what's written to it concurrently,
+// writing each as its own gzip member. Concatenated members are a valid gzip
+// stream, which gzip and tar read like any other.
+type parallelGzipWriter struct {
+	out io.Writer
+
+	block []byte
+
+	// compressed blocks are queued in the order they were written, and
+	// written out in that order as each finishes
+	queue   chan chan []byte
+	written chan struct{}
+
+	errLock sync.Mutex
+	err     error
+
+	wroteBlock bool
+}
+
+func newParallelGzipWriter(out io.Writer) *parallelGzipWriter {
+	writer := &parallelGzipWriter{
+		out:     out,
+		block:   make([]byte, 0, compressionBlockSize),
+		queue:   make(chan chan []byte, runtime.NumCPU()),
+		written: make(chan struct{}),
+	}
+
+	go writer.writeBlocks()
+
+	return writer
+}
+
+func (writer *parallelGzipWriter) Write(p []byte) (int, error) {
+	written := 0
+
+	for len(p) > 0 {
+		if err := writer.error(); err != nil {
+			return written, err
+		}
+
+		n := cap(writer.block) - len(writer.block)
+		if n > len(p) {
+			n = len(p)
+		}
+
+		writer.block = append(writer.block, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(writer.block) == cap(writer.block) {
+			writer.flushBlock()
+		}
+	}
+
+	return written, nil
+}
+
+// Close compresses whatever is left and waits for every block to be written.
+func (writer *parallelGzipWriter) Close() error {
+	// an empty stream still needs a member to be valid gzip
+	if len(writer.block) > 0 || !writer.wroteBlock {
+		writer.flushBlock()
+	}
+
+	close(writer.queue)
+	<-writer.written
+
+	return writer.error()
+}
+
+func (writer *parallelGzipWriter) flushBlock() {
+	block := writer.block
+	writer.block = make([]byte, 0, compressionBlockSize)
+	writer.wroteBlock = true
+
+	compressed := make(chan []byte, 1)
+	writer.queue <- compressed
+
+	go func() {
+		buf := new(bytes.Buffer)
+		// incompressible data grows slightly
+		buf.Grow(len(block) + len(block)/100 + 64)
+
+		gzWriter := gzipWriters.Get().(*gzip.Writer)
+		gzWriter.Reset(buf)
+		gzWriter.Write(block)
+		gzWriter.Close()
+		gzipWriters.Put(gzWriter)
+
+		compressed <- buf.Bytes()
+	}()
+}
+
+func (writer *parallelGzipWriter) writeBlocks() {
+	defer close(writer.written)
+
+	for compressed := range writer.queue {
+		block := <-compressed
+
+		if writer.error() != nil {
+			continue
+		}
+
+		_, err := writer.out.Write(block)
+		if err != nil {
+			writer.errLock.Lock()
+			writer.err = err
+			writer.errLock.Unlock()
+		}
+	}
+}
+
+func (writer *parallelGzipWriter) error() error {
+	writer.errLock.Lock()
+	defer writer.errLock.Unlock()
+
+	return writer.err
+}
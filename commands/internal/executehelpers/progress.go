@@ -0,0 +1,312 @@
+package executehelpers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/concourse/fly/pty"
+	"github.com/mattn/go-isatty"
+)
+
+const progressRedrawInterval = 200 * time.Millisecond
+
+// progressBoard draws the progress of concurrent transfers together on one
+// status line, which is redrawn in place. The cursor is never moved up, so
+// the build's output and other messages printed meanwhile are never drawn
+// over; messages are printed through the board so that the status line is
+// cleared first and drawn again beneath them.
+type progressBoard struct {
+	out io.Writer
+
+	// columns returns the width of the terminal, so that the status line
+	// never wraps; it is not truncated when nil or when it returns 0
+	columns func() int
+
+	lock      sync.Mutex
+	nextID    int
+	transfers []boardTransfer
+}
+
+type boardTransfer struct {
+	id     int
+	status string
+}
+
+var stderrProgress = &progressBoard{
+	out: os.Stderr,
+	columns: func() int {
+		_, cols, err := pty.Getsize(os.Stderr)
+		if err != nil {
+			return 0
+		}
+
+		return cols
+	},
+}
+
+// add starts showing a new transfer, returning its id.
+func (board *progressBoard) add() int {
+	board.lock.Lock()
+	defer board.lock.Unlock()
+
+	id := board.nextID
+	board.nextID++
+
+	board.transfers = append(board.transfers, boardTransfer{id: id})
+
+	return id
+}
+
+// set replaces the status of a transfer and redraws the status line.
+func (board *progressBoard) set(id int, status string) {
+	board.lock.Lock()
+	defer board.lock.Unlock()
+
+	for i := range board.transfers {
+		if board.transfers[i].id == id {
+			board.transfers[i].status = status
+		}
+	}
+
+	board.draw()
+}
+
+// finish stops showing a transfer, printing its summary on a line of its
+// own.
+func (board *progressBoard) finish(id int, summary string) {
+	board.lock.Lock()
+	defer board.lock.Unlock()
+
+	remaining := []boardTransfer{}
+	for _, transfer := range board.transfers {
+		if transfer.id != id {
+			remaining = append(remaining, transfer)
+		}
+	}
+
+	board.transfers = remaining
+
+	fmt.Fprintf(board.out, "\r\x1b[K%s\n", summary)
+	board.draw()
+}
+
+// printf prints a message on a line of its own, beneath which the status
+// line is drawn again.
+func (board *progressBoard) printf(format string, args ...interface{}) {
+	board.lock.Lock()
+	defer board.lock.Unlock()
+
+	if len(board.transfers) > 0 {
+		fmt.Fprint(board.out, "\r\x1b[K")
+	}
+
+	fmt.Fprintf(board.out, format, args...)
+	board.draw()
+}
+
+func (board *progressBoard) draw() {
+	statuses := []string{}
+	for _, transfer := range board.transfers {
+		if transfer.status != "" {
+			statuses = append(statuses, transfer.status)
+		}
+	}
+
+	if len(statuses) == 0 {
+		return
+	}
+
+	line := []rune(strings.Join(statuses, "; "))
+
+	// leave the last column free, as some terminals wrap on filling it
+	if board.columns != nil {
+		if columns := board.columns(); columns > 1 && len(line) > columns-1 {
+			line = line[:columns-1]
+		}
+	}
+
+	fmt.Fprintf(board.out, "\r\x1b[K%s", string(line))
+}
+
+// transferProgress reports the bytes moved for a single input or output,
+// along with the rate and, when the total size is known, an ETA. It only
+// draws when stderr is a terminal.
+type transferProgress struct {
+	verb     string
+	pastVerb string
+	name     string
+
+	total       int64
+	transferred int64
+
+	board *progressBoard
+	id    int
+
+	// an upload is measured as it's archived, which may still be under way
+	// when the upload fails
+	lock     sync.Mutex
+	started  time.Time
+	lastDraw time.Time
+}
+
+// newTransferProgress returns a progress reporter for a transfer of total
+// bytes; a negative total means the size is not known up front.
+func newTransferProgress(verb string, pastVerb string, name string, total int64) *transferProgress {
+	var board *progressBoard
+	if isatty.IsTerminal(os.Stderr.Fd()) {
+		board = stderrProgress
+	}
+
+	return newTransferProgressOn(board, verb, pastVerb, name, total)
+}
+
+func newTransferProgressOn(board *progressBoard, verb string, pastVerb string, name string, total int64) *transferProgress {
+	progress := &transferProgress{
+		verb:     verb,
+		pastVerb: pastVerb,
+		name:     name,
+		total:    total,
+		board:    board,
+		started:  time.Now(),
+	}
+
+	if board != nil {
+		progress.id = board.add()
+	}
+
+	return progress
+}
+
+func (progress *transferProgress) wrap(r io.Reader) io.Reader {
+	if progress.board == nil {
+		return r
+	}
+
+	return &progressReader{Reader: r, progress: progress}
+}
+
+// Write counts the bytes written as transferred, for transfers that are
+// measured as they're produced rather than as they're read.
+func (progress *transferProgress) Write(p []byte) (int, error) {
+	if progress.board != nil {
+		progress.add(len(p))
+	}
+
+	return len(p), nil
+}
+
+func (progress *transferProgress) add(n int) {
+	progress.lock.Lock()
+	defer progress.lock.Unlock()
+
+	progress.transferred += int64(n)
+
+	if time.Since(progress.lastDraw) < progressRedrawInterval {
+		return
+	}
+
+	progress.lastDraw = time.Now()
+	progress.board.set(progress.id, progress.status(time.Since(progress.started)))
+}
+
+// done prints a summary of the whole transfer in place of its progress.
+func (progress *transferProgress) done() {
+	if progress.board == nil {
+		return
+	}
+
+	progress.lock.Lock()
+	defer progress.lock.Unlock()
+
+	progress.board.finish(progress.id, progress.summary(time.Since(progress.started)))
+}
+
+func (progress *transferProgress) rate(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(progress.transferred) / elapsed.Seconds()
+}
+
+func (progress *transferProgress) status(elapsed time.Duration) string {
+	rate := progress.rate(elapsed)
+
+	if progress.total < 0 {
+		return fmt.Sprintf(
+			"%s %s: %s %s/s",
+			progress.verb,
+			progress.name,
+			humanizeBytes(progress.transferred),
+			humanizeBytes(int64(rate)),
+		)
+	}
+
+	line := fmt.Sprintf(
+		"%s %s: %s / %s (%d%%) %s/s",
+		progress.verb,
+		progress.name,
+		humanizeBytes(progress.transferred),
+		humanizeBytes(progress.total),
+		percent(progress.transferred, progress.total),
+		humanizeBytes(int64(rate)),
+	)
+
+	if rate > 0 && progress.transferred < progress.total {
+		remaining := float64(progress.total-progress.transferred) / rate
+		line += fmt.Sprintf(", eta %s", time.Duration(remaining*float64(time.Second)).Round(time.Second))
+	}
+
+	return line
+}
+
+func (progress *transferProgress) summary(elapsed time.Duration) string {
+	return fmt.Sprintf(
+		"%s %s: %s in %s (%s/s)",
+		progress.pastVerb,
+		progress.name,
+		humanizeBytes(progress.transferred),
+		elapsed.Round(time.Millisecond),
+		humanizeBytes(int64(progress.rate(elapsed))),
+	)
+}
+
+type progressReader struct {
+	io.Reader
+
+	progress *transferProgress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.progress.add(n)
+	return n, err
+}
+
+func percent(n int64, total int64) int64 {
+	if total == 0 {
+		return 100
+	}
+
+	return n * 100 / total
+}
+
+func humanizeBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
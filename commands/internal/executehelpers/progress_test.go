@@ -0,0 +1,87 @@
+package executehelpers_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+)
+
+var _ = Describe("Transfer progress", func() {
+	var out *bytes.Buffer
+	var board executehelpers.ProgressBoard
+
+	BeforeEach(func() {
+		out = new(bytes.Buffer)
+		board = executehelpers.NewProgressBoard(out, 80)
+	})
+
+	It("shows the bytes transferred, the rate and, when the size is known, an ETA", func() {
+		progress := board.Start("uploading", "uploaded", "some-input", 2*1024*1024)
+		progress.Transferred(512 * 1024)
+
+		Expect(progress.Status(time.Second)).To(Equal("uploading some-input: 512.0KiB / 2.0MiB (25%) 512.0KiB/s, eta 3s"))
+	})
+
+	It("leaves out the total and ETA when the size is not known", func() {
+		progress := board.Start("downloading", "downloaded", "some-output", -1)
+		progress.Transferred(3 * 1024)
+
+		Expect(progress.Status(2 * time.Second)).To(Equal("downloading some-output: 3.0KiB 1.5KiB/s"))
+	})
+
+	It("summarizes the whole transfer", func() {
+		progress := board.Start("downloading", "downloaded", "some-output", -1)
+		progress.Transferred(10 * 1024 * 1024)
+
+		Expect(progress.Summary(4 * time.Second)).To(Equal("downloaded some-output: 10.0MiB in 4s (2.5MiB/s)"))
+	})
+
+	It("draws every transfer together on one line, redrawn in place", func() {
+		first := board.Start("downloading", "downloaded", "first", -1)
+		second := board.Start("downloading", "downloaded", "second", -1)
+
+		Expect(out.String()).To(BeEmpty())
+
+		board.Set(second, "second's progress")
+		Expect(out.String()).To(Equal("\r\x1b[Ksecond's progress"))
+		out.Reset()
+
+		board.Set(first, "first's progress")
+		Expect(out.String()).To(Equal("\r\x1b[Kfirst's progress; second's progress"))
+		out.Reset()
+
+		first.Done()
+		Expect(out.String()).To(HavePrefix("\r\x1b[Kdownloaded first: 0B in "))
+		Expect(out.String()).To(HaveSuffix("\n\r\x1b[Ksecond's progress"))
+		Expect(out.String()).NotTo(ContainSubstring("\x1b[1A"))
+	})
+
+	It("prints messages above the status line", func() {
+		progress := board.Start("uploading", "uploaded", "some-input", -1)
+		board.Set(progress, "some progress")
+		out.Reset()
+
+		board.Printf("retrying in %s...\n", time.Second)
+
+		Expect(out.String()).To(Equal("\r\x1b[Kretrying in 1s...\n\r\x1b[Ksome progress"))
+	})
+
+	It("prints messages as they are when nothing is in progress", func() {
+		board.Printf("retrying in %s...\n", time.Second)
+
+		Expect(out.String()).To(Equal("retrying in 1s...\n"))
+	})
+
+	It("keeps the status line narrower than the terminal", func() {
+		board = executehelpers.NewProgressBoard(out, 10)
+
+		progress := board.Start("uploading", "uploaded", "some-input", -1)
+		board.Set(progress, "some very long progress")
+
+		Expect(out.String()).To(Equal("\r\x1b[Ksome very"))
+	})
+})
@@ -3,55 +3,250 @@ package executehelpers
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
 	"os/exec"
+	"time"
 
 	"github.com/concourse/go-concourse/concourse"
 )
 
-func Upload(client concourse.Client, input Input, excludeIgnored bool) {
+const initialUploadBackoff = time.Second
+
+type uploadError struct {
+	err       error
+	retryable bool
+}
+
+func (err uploadError) Error() string {
+	return err.err.Error()
+}
+
+// UploadOptions controls what is uploaded for an input, and how.
+type UploadOptions struct {
+	// ExcludeIgnored skips paths that git ignores.
+	ExcludeIgnored bool
+
+	// Excludes are patterns, in .gitignore syntax, to skip in addition to
+	// those in the input's .flyignore.
+	Excludes []string
+
+	// Attempts is the number of times to try the upload.
+	Attempts int
+
+	// CacheDir is where archives are kept between runs, so that an input
+	// that has not changed is not archived again. Caching is disabled when
+	// it is empty.
+	CacheDir string
+}
+
+// Upload streams the input's contents to its pipe, retrying with exponential
+// backoff up to the given number of attempts. The task may already have read
+// whatever part of the archive was sent, so an upload is only retried if none
+// of it was. Paths matching the input's .flyignore or any of the exclude
+// patterns are left out.
+func Upload(client concourse.Client, input Input, options UploadOptions) error {
 	path := input.Path
-	pipe := input.Pipe
 
+	files, flat, err := selectFiles(path, options.ExcludeIgnored, options.Excludes)
+	if err != nil {
+		return fmt.Errorf("failed to upload input '%s': %s", input.Name, err)
+	}
+
+	source := archiveSource{
+		input: input,
+		files: files,
+		flat:  flat,
+		cache: inputCache{dir: options.CacheDir},
+	}
+
+	if source.cache.enabled() {
+		source.fingerprint, err = Fingerprint(path, files, flat)
+		if err != nil {
+			// not being able to cache is no reason to fail the upload
+			source.cache = inputCache{}
+		}
+	}
+
+	backoff := initialUploadBackoff
+
+	for attempt := 1; ; attempt++ {
+		err = uploadOnce(client, source)
+		if err == nil {
+			return nil
+		}
+
+		if uploadErr, ok := err.(uploadError); ok && !uploadErr.retryable {
+			break
+		}
+
+		if attempt >= options.Attempts {
+			break
+		}
+
+		// other inputs may still be showing their progress
+		stderrProgress.printf("uploading input '%s' failed (attempt %d of %d): %s\n", input.Name, attempt, options.Attempts, err)
+		stderrProgress.printf("retrying in %s...\n", backoff)
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return fmt.Errorf("failed to upload input '%s': %s", input.Name, err)
+}
+
+// selectFiles returns the paths under dir to upload. When flat is returned,
+// the paths have already been expanded and filtered, and directories must not
+// be recursed into.
+func selectFiles(dir string, excludeIgnored bool, excludes []string) ([]string, bool, error) {
 	var files []string
 	var err error
 
 	if excludeIgnored {
-		files, err = getGitFiles(path)
+		files, err = getGitFiles(dir)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "could not determine ignored files:", err)
-			return
+			return nil, false, fmt.Errorf("could not determine ignored files: %s", err)
 		}
 	} else {
 		files = []string{"."}
 	}
 
-	archive, err := tarStreamFrom(path, files)
+	excluder, err := LoadExcluder(dir, excludes)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "could create tar stream:", err)
-		return
+		return nil, false, err
+	}
+
+	if excluder.Empty() {
+		return files, false, nil
+	}
+
+	files, err = filterPaths(dir, files, excluder)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not determine excluded files: %s", err)
+	}
+
+	return files, true, nil
+}
+
+// archiveSource knows how to produce the archive of an input, either from a
+// previous run's cache or by building it.
+type archiveSource struct {
+	input Input
+	files []string
+	flat  bool
+
+	cache       inputCache
+	fingerprint string
+}
+
+func uploadOnce(client concourse.Client, source archiveSource) error {
+	input := source.input
+
+	var archive io.ReadCloser
+	var progress *transferProgress
+	var recorder *cacheRecorder
+
+	// the files may have changed since they were fingerprinted, so the
+	// archive is cached under a fingerprint of what actually went into it
+	archived := sha256.New()
+
+	cachedArchive, cachedSize, cached := source.cache.lookup(input.Path, source.fingerprint)
+	if cached {
+		stderrProgress.printf("input '%s' is unchanged; reusing its archive from the last run\n", input.Name)
+
+		progress = newTransferProgress("uploading", "uploaded", input.Name, cachedSize)
+		archive = cachedArchive
+	} else {
+		// the size of the compressed archive isn't known until it's been
+		// sent, so progress is measured by how much of the input it has taken
+		size, err := contentSize(input.Path, source.files, source.flat)
+		if err != nil {
+			size = -1
+		}
+
+		progress = newTransferProgress("uploading", "uploaded", input.Name, size)
+
+		built, err := archiveStream(input.Path, source.files, source.flat, newCompressor, progress, archived)
+		if err != nil {
+			progress.done()
+			return uploadError{err: fmt.Errorf("could not create archive: %s", err)}
+		}
+
+		archive = built
+
+		if source.cache.enabled() {
+			recorder, err = source.cache.record(input.Path, built)
+			if err == nil {
+				archive = recorder
+			}
+		}
 	}
 
+	defer progress.done()
 	defer archive.Close()
 
-	upload, err := http.NewRequest("PUT", pipe.WriteURL, archive)
+	sent := &countingReader{Reader: archive}
+
+	var body io.Reader = sent
+	if cached {
+		body = progress.wrap(sent)
+	}
+
+	err := sendArchive(client, input, body)
+	if uploadErr, ok := err.(uploadError); ok && sent.count > 0 {
+		// the pipe can't be rewound, so sending the archive again would give
+		// the task the start of it twice
+		uploadErr.retryable = false
+		err = uploadErr
+	}
+
+	if recorder != nil {
+		if err == nil {
+			recorder.commit(hex.EncodeToString(archived.Sum(nil)))
+		} else {
+			recorder.discard()
+		}
+	}
+
+	return err
+}
+
+func sendArchive(client concourse.Client, input Input, archive io.Reader) error {
+	upload, err := http.NewRequest("PUT", input.Pipe.WriteURL, archive)
 	if err != nil {
-		panic(err)
+		return uploadError{err: err}
 	}
 
 	response, err := client.HTTPClient().Do(upload)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "upload request failed:", err)
-		return
+		return uploadError{err: fmt.Errorf("upload request failed: %s", err), retryable: true}
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		fmt.Fprintln(os.Stderr, badResponseError("uploading bits", response))
+		return uploadError{
+			err:       badResponseError("uploading bits", response),
+			retryable: response.StatusCode >= 500,
+		}
 	}
+
+	return nil
+}
+
+type countingReader struct {
+	io.Reader
+
+	count int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.Reader.Read(p)
+	reader.count += int64(n)
+	return n, err
 }
 
 func getGitFiles(dir string) ([]string, error) {
@@ -0,0 +1,170 @@
+package executehelpers_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/executehelpers"
+	fakes "github.com/concourse/go-concourse/concourse/fakes"
+)
+
+// uploadTransport fails the first failures requests, after reading readFirst
+// bytes of each one's body.
+type uploadTransport struct {
+	failures  int
+	readFirst int64
+
+	requests int
+}
+
+func (transport *uploadTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	transport.requests++
+
+	if transport.requests <= transport.failures {
+		io.CopyN(ioutil.Discard, r.Body, transport.readFirst)
+		return nil, errors.New("connection reset by peer")
+	}
+
+	ioutil.ReadAll(r.Body)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+var _ = Describe("Upload", func() {
+	var dir string
+	var transport *uploadTransport
+	var client *fakes.FakeClient
+	var input executehelpers.Input
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-upload")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		transport = &uploadTransport{}
+
+		client = new(fakes.FakeClient)
+		client.HTTPClientReturns(&http.Client{Transport: transport})
+
+		input = executehelpers.Input{
+			Name: "some-input",
+			Path: dir,
+			Pipe: atc.Pipe{ID: "some-pipe", WriteURL: "http://example.com/api/v1/pipes/some-pipe"},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	upload := func(attempts int) error {
+		return executehelpers.Upload(client, input, executehelpers.UploadOptions{
+			Attempts: attempts,
+		})
+	}
+
+	Context("when a request fails before any of the archive is sent", func() {
+		BeforeEach(func() {
+			transport.failures = 1
+		})
+
+		It("retries the upload", func() {
+			Expect(upload(2)).To(Succeed())
+			Expect(transport.requests).To(Equal(2))
+		})
+
+		It("gives up after the given number of attempts", func() {
+			transport.failures = 100
+
+			err := upload(2)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to upload input 'some-input'"))
+
+			Expect(transport.requests).To(Equal(2))
+		})
+	})
+
+	Context("when caching archives", func() {
+		var cacheDir string
+
+		BeforeEach(func() {
+			var err error
+			cacheDir, err = ioutil.TempDir("", "fly-upload-cache")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(cacheDir)
+		})
+
+		uploadCached := func() error {
+			return executehelpers.Upload(client, input, executehelpers.UploadOptions{
+				Attempts: 1,
+				CacheDir: cacheDir,
+			})
+		}
+
+		It("keeps the archive under the fingerprint of what went into it", func() {
+			Expect(uploadCached()).To(Succeed())
+
+			fingerprint, err := executehelpers.Fingerprint(dir, []string{"."}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Glob(filepath.Join(cacheDir, "*-"+fingerprint+".tgz"))).To(HaveLen(1))
+		})
+
+		It("keeps only the latest archive of each input", func() {
+			Expect(uploadCached()).To(Succeed())
+
+			err := ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(uploadCached()).To(Succeed())
+
+			Expect(filepath.Glob(filepath.Join(cacheDir, "*.tgz"))).To(HaveLen(1))
+		})
+
+		It("removes the least recently used archives once there are too many", func() {
+			for i := 0; i < 10; i++ {
+				inputDir, err := ioutil.TempDir(dir, "input")
+				Expect(err).NotTo(HaveOccurred())
+
+				input.Path = inputDir
+				Expect(uploadCached()).To(Succeed())
+			}
+
+			Expect(filepath.Glob(filepath.Join(cacheDir, "*.tgz"))).To(HaveLen(8))
+		})
+	})
+
+	Context("when a request fails after part of the archive is sent", func() {
+		BeforeEach(func() {
+			transport.failures = 1
+			transport.readFirst = 1
+		})
+
+		It("does not send it again", func() {
+			err := upload(5)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to upload input 'some-input'"))
+
+			Expect(transport.requests).To(Equal(1))
+		})
+	})
+})
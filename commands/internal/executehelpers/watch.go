@@ -0,0 +1,164 @@
+package executehelpers
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const watchDebounce = 300 * time.Millisecond
+
+type fileState struct {
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+type snapshot map[string]fileState
+
+func (s snapshot) equal(other snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+
+	for path, state := range s {
+		otherState, found := other[path]
+		if !found || otherState.size != state.size || otherState.mode != state.mode || !otherState.modTime.Equal(state.modTime) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Watcher polls input directories and individual files for changes, looking
+// only at the paths that would be uploaded. Each poll walks every input, so
+// the interval should be long enough not to load large inputs' disks.
+type Watcher struct {
+	dirs  []string
+	files []string
+
+	excludeIgnored bool
+	excludes       []string
+
+	interval time.Duration
+
+	last snapshot
+}
+
+// NewWatcher returns a watcher for the given input directories and files,
+// applying the same exclusions as Upload does to the directories, that polls
+// them at the given interval.
+func NewWatcher(dirs []string, files []string, excludeIgnored bool, excludes []string, interval time.Duration) *Watcher {
+	watcher := &Watcher{
+		dirs:           dirs,
+		files:          files,
+		excludeIgnored: excludeIgnored,
+		excludes:       excludes,
+		interval:       interval,
+	}
+
+	watcher.last = watcher.snapshot()
+
+	return watcher
+}
+
+// Watch sends on the returned channel whenever something changes, once the
+// changes have settled. Changes made while nobody is receiving are coalesced
+// into one. It stops when stop is closed.
+func (watcher *Watcher) Watch(stop <-chan struct{}) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		ticker := time.NewTicker(watcher.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			current := watcher.snapshot()
+			if current.equal(watcher.last) {
+				continue
+			}
+
+			// wait for a quiet period, so that e.g. an editor saving several
+			// files results in a single change
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(watchDebounce):
+				}
+
+				settled := watcher.snapshot()
+				if settled.equal(current) {
+					break
+				}
+
+				current = settled
+			}
+
+			watcher.last = current
+
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes
+}
+
+// snapshot records the state of every watched path. Paths that can't be read
+// are left out, so that e.g. a file being replaced shows up as a change
+// rather than an error.
+func (watcher *Watcher) snapshot() snapshot {
+	state := snapshot{}
+
+	for _, dir := range watcher.dirs {
+		paths, flat, err := selectFiles(dir, watcher.excludeIgnored, watcher.excludes)
+		if err != nil {
+			continue
+		}
+
+		entries, err := listEntries(dir, paths, flat)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			recordState(state, filepath.Join(dir, entry))
+		}
+	}
+
+	for _, file := range watcher.files {
+		recordState(state, file)
+	}
+
+	return state
+}
+
+func recordState(state snapshot, path string) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+
+	// a directory's modification time changes whenever anything is created
+	// in it, including excluded files like editor swap files; files coming
+	// and going are noticed by themselves
+	if info.IsDir() {
+		return
+	}
+
+	state[path] = fileState{
+		size:    info.Size(),
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+	}
+}
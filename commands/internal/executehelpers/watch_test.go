@@ -0,0 +1,140 @@
+package executehelpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/commands/internal/executehelpers"
+)
+
+var _ = Describe("Watcher", func() {
+	var dir string
+	var configFile string
+
+	var stop chan struct{}
+	var changes <-chan struct{}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "fly-watch")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "script.sh"), []byte("echo hello"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, ".flyignore"), []byte("*.swp\n"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		configFile = filepath.Join(dir, "task.yml")
+
+		err = ioutil.WriteFile(configFile, []byte("---\n"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		stop = make(chan struct{})
+
+		watcher := executehelpers.NewWatcher([]string{dir}, []string{configFile}, false, []string{"scratch/"}, 100*time.Millisecond)
+		changes = watcher.Watch(stop)
+	})
+
+	AfterEach(func() {
+		close(stop)
+		os.RemoveAll(dir)
+	})
+
+	It("notices a file being changed", func() {
+		err := ioutil.WriteFile(filepath.Join(dir, "script.sh"), []byte("echo goodbye, world"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(changes, 5).Should(Receive())
+	})
+
+	It("notices a file being added", func() {
+		err := ioutil.WriteFile(filepath.Join(dir, "new.sh"), []byte(""), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(changes, 5).Should(Receive())
+	})
+
+	It("notices a file being removed", func() {
+		err := os.Remove(filepath.Join(dir, "script.sh"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(changes, 5).Should(Receive())
+	})
+
+	It("notices the watched file changing", func() {
+		err := ioutil.WriteFile(configFile, []byte("---\nplatform: linux\n"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(changes, 5).Should(Receive())
+	})
+
+	It("coalesces a burst of changes into one", func() {
+		for _, name := range []string{"a", "b", "c"} {
+			err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Eventually(changes, 5).Should(Receive())
+		Consistently(changes, 2).ShouldNot(Receive())
+	})
+
+	It("ignores paths excluded by .flyignore", func() {
+		err := ioutil.WriteFile(filepath.Join(dir, ".script.sh.swp"), []byte("swap"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Consistently(changes, 2).ShouldNot(Receive())
+	})
+
+	It("ignores paths excluded by the given patterns", func() {
+		err := os.MkdirAll(filepath.Join(dir, "scratch"), 0755)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(dir, "scratch", "notes"), []byte("notes"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		Consistently(changes, 2).ShouldNot(Receive())
+	})
+
+	Context("when only the files known to git are watched", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(dir, ".flyignore"), []byte("node_modules/\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.MkdirAll(filepath.Join(dir, "node_modules", "pkg"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(dir, "node_modules", "pkg", "index.js"), []byte(""), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			git(dir, "init", "-q")
+			git(dir, "add", ".")
+			git(dir, "commit", "-q", "-m", "initial")
+
+			watcher := executehelpers.NewWatcher([]string{dir}, []string{configFile}, true, nil, 100*time.Millisecond)
+			changes = watcher.Watch(stop)
+		})
+
+		It("notices a tracked file being changed", func() {
+			err := ioutil.WriteFile(filepath.Join(dir, "script.sh"), []byte("echo goodbye, world"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(changes, 5).Should(Receive())
+		})
+
+		It("ignores files within directories excluded by .flyignore", func() {
+			err := ioutil.WriteFile(filepath.Join(dir, "node_modules", "pkg", "index.js"), []byte("changed"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(dir, "node_modules", "pkg", "new.js"), []byte(""), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Consistently(changes, 2).ShouldNot(Receive())
+		})
+	})
+})
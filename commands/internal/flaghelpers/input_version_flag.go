@@ -0,0 +1,31 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/atc"
+)
+
+type InputVersionFlag struct {
+	Name    string
+	Version atc.Version
+}
+
+func (flag *InputVersionFlag) UnmarshalFlag(value string) error {
+	vs := strings.SplitN(value, "=", 2)
+	if len(vs) != 2 || vs[0] == "" {
+		return fmt.Errorf("invalid input version '%s' (must be name=key:value[,key:value...])", value)
+	}
+
+	var version VersionFlag
+	err := version.UnmarshalFlag(vs[1])
+	if err != nil {
+		return err
+	}
+
+	flag.Name = vs[0]
+	flag.Version = atc.Version(version)
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package flaghelpers_test
+
+import (
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InputVersionFlag", func() {
+	It("parses the input name and its version", func() {
+		flag := &InputVersionFlag{}
+
+		err := flag.UnmarshalFlag("some-input=ref:abc123,branch:master")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(flag.Name).To(Equal("some-input"))
+		Expect(flag.Version).To(Equal(atc.Version{"ref": "abc123", "branch": "master"}))
+	})
+
+	Context("when there is no name", func() {
+		It("displays an error message", func() {
+			flag := &InputVersionFlag{}
+
+			err := flag.UnmarshalFlag("ref:abc123")
+			Expect(err).To(MatchError("invalid input version 'ref:abc123' (must be name=key:value[,key:value...])"))
+		})
+	})
+
+	Context("when the version is malformed", func() {
+		It("displays an error message", func() {
+			flag := &InputVersionFlag{}
+
+			err := flag.UnmarshalFlag("some-input=abc123")
+			Expect(err).To(MatchError("invalid version 'abc123' (must be key:value[,key:value...])"))
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package flaghelpers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/concourse/go-concourse/concourse"
+)
+
+type JobBuildFlag struct {
+	PipelineName string
+	JobName      string
+	BuildName    string
+}
+
+func (flag *JobBuildFlag) UnmarshalFlag(value string) error {
+	vs := strings.SplitN(value, "/", 3)
+
+	if len(vs) != 3 {
+		return errors.New("argument format should be <pipeline>/<job>/<build>")
+	}
+
+	if vs[0] == "" {
+		return concourse.NameRequiredError("pipeline")
+	}
+
+	if vs[1] == "" {
+		return concourse.NameRequiredError("job")
+	}
+
+	if vs[2] == "" {
+		return concourse.NameRequiredError("build")
+	}
+
+	flag.PipelineName = vs[0]
+	flag.JobName = vs[1]
+	flag.BuildName = vs[2]
+
+	return nil
+}
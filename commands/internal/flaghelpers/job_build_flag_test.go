@@ -0,0 +1,40 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/go-concourse/concourse"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JobBuildFlag", func() {
+	It("parses the pipeline, job and build", func() {
+		flag := &JobBuildFlag{}
+
+		err := flag.UnmarshalFlag("some-pipeline/some-job/42")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(flag.PipelineName).To(Equal("some-pipeline"))
+		Expect(flag.JobName).To(Equal("some-job"))
+		Expect(flag.BuildName).To(Equal("42"))
+	})
+
+	Context("when the build is not specified", func() {
+		It("displays an error message", func() {
+			flag := &JobBuildFlag{}
+
+			err := flag.UnmarshalFlag("some-pipeline/some-job")
+			Expect(err).To(MatchError("argument format should be <pipeline>/<job>/<build>"))
+		})
+	})
+
+	Context("when a part is empty", func() {
+		It("says which one is missing", func() {
+			flag := &JobBuildFlag{}
+
+			err := flag.UnmarshalFlag("some-pipeline//42")
+			Expect(err).To(Equal(concourse.NameRequiredError("job")))
+		})
+	})
+})
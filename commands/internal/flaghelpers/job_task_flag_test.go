@@ -0,0 +1,40 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/go-concourse/concourse"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JobTaskFlag", func() {
+	It("parses the pipeline, job and step", func() {
+		flag := &JobTaskFlag{}
+
+		err := flag.UnmarshalFlag("some-pipeline/some-job/some-step")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(flag.PipelineName).To(Equal("some-pipeline"))
+		Expect(flag.JobName).To(Equal("some-job"))
+		Expect(flag.StepName).To(Equal("some-step"))
+	})
+
+	Context("when the step is not specified", func() {
+		It("displays an error message", func() {
+			flag := &JobTaskFlag{}
+
+			err := flag.UnmarshalFlag("some-pipeline/some-job")
+			Expect(err).To(MatchError("argument format should be <pipeline>/<job>/<step>"))
+		})
+	})
+
+	Context("when a part is empty", func() {
+		It("says which one is missing", func() {
+			flag := &JobTaskFlag{}
+
+			err := flag.UnmarshalFlag("some-pipeline//some-step")
+			Expect(err).To(Equal(concourse.NameRequiredError("job")))
+		})
+	})
+})
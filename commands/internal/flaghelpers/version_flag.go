@@ -0,0 +1,27 @@
+package flaghelpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/concourse/atc"
+)
+
+type VersionFlag atc.Version
+
+func (version *VersionFlag) UnmarshalFlag(value string) error {
+	parsed := VersionFlag{}
+
+	for _, pair := range strings.Split(value, ",") {
+		vs := strings.SplitN(pair, ":", 2)
+		if len(vs) != 2 || vs[0] == "" {
+			return fmt.Errorf("invalid version '%s' (must be key:value[,key:value...])", value)
+		}
+
+		parsed[vs[0]] = vs[1]
+	}
+
+	*version = parsed
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package flaghelpers_test
+
+import (
+	. "github.com/concourse/fly/commands/internal/flaghelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VersionFlag", func() {
+	It("parses a single key:value pair", func() {
+		versionFlag := VersionFlag{}
+
+		err := versionFlag.UnmarshalFlag("ref:abcdef")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versionFlag).To(Equal(VersionFlag{"ref": "abcdef"}))
+	})
+
+	It("parses multiple comma-separated pairs, keeping colons in values", func() {
+		versionFlag := VersionFlag{}
+
+		err := versionFlag.UnmarshalFlag("ref:abcdef,time:12:30")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versionFlag).To(Equal(VersionFlag{"ref": "abcdef", "time": "12:30"}))
+	})
+
+	Context("when a pair has no value", func() {
+		It("displays an error message", func() {
+			versionFlag := VersionFlag{}
+
+			err := versionFlag.UnmarshalFlag("ref")
+			Expect(err).To(MatchError("invalid version 'ref' (must be key:value[,key:value...])"))
+		})
+	})
+})
@@ -11,7 +11,6 @@ import (
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/template"
 	"github.com/concourse/go-concourse/concourse"
-	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/rata"
 	"github.com/vito/go-interact/interact"
 	"gopkg.in/yaml.v2"
@@ -22,6 +21,9 @@ type ATCConfig struct {
 	Client              concourse.Client
 	WebRequestGenerator *rata.RequestGenerator
 	SkipInteraction     bool
+	DiffFormat          string
+	SecretVars          []string
+	VarsProviders       []template.Provider
 }
 
 func (atcConfig ATCConfig) ApplyConfigInteraction() bool {
@@ -39,13 +41,16 @@ func (atcConfig ATCConfig) ApplyConfigInteraction() bool {
 }
 
 func (atcConfig ATCConfig) Set(configPath flaghelpers.PathFlag, templateVariables template.Variables, templateVariablesFiles []flaghelpers.PathFlag) error {
-	newConfig := atcConfig.newConfig(configPath, templateVariablesFiles, templateVariables)
+	newConfig, secrets := atcConfig.newConfig(configPath, templateVariablesFiles, templateVariables)
 	existingConfig, existingConfigVersion, _, err := atcConfig.Client.PipelineConfig(atcConfig.PipelineName)
 	if err != nil {
 		return err
 	}
 
-	diff(existingConfig, newConfig)
+	err = atcConfig.showDiffs(DiffConfigs(existingConfig, newConfig), secrets)
+	if err != nil {
+		return err
+	}
 
 	if !atcConfig.ApplyConfigInteraction() {
 		displayhelpers.Failf("bailing out")
@@ -61,20 +66,63 @@ func (atcConfig ATCConfig) Set(configPath flaghelpers.PathFlag, templateVariable
 	}
 
 	if len(warnings) > 0 {
-		atcConfig.showWarnings(warnings)
+		atcConfig.showWarnings(warnings, secrets)
 	}
 
 	atcConfig.showHelpfulMessage(created, updated)
 	return nil
 }
 
-func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, templateVariablesFiles []flaghelpers.PathFlag, templateVariables template.Variables) atc.Config {
+// DryRun shows what Set would change, without changing anything, and returns
+// whether there are any changes.
+func (atcConfig ATCConfig) DryRun(configPath flaghelpers.PathFlag, templateVariables template.Variables, templateVariablesFiles []flaghelpers.PathFlag) (bool, error) {
+	newConfig, secrets := atcConfig.newConfig(configPath, templateVariablesFiles, templateVariables)
+	existingConfig, _, _, err := atcConfig.Client.PipelineConfig(atcConfig.PipelineName)
+	if err != nil {
+		return false, err
+	}
+
+	diffs := DiffConfigs(existingConfig, newConfig)
+
+	err = atcConfig.showDiffs(diffs, secrets)
+	if err != nil {
+		return false, err
+	}
+
+	if diffs.Empty() && (atcConfig.DiffFormat == "" || atcConfig.DiffFormat == "text") {
+		fmt.Fprintln(os.Stderr, "no changes to apply")
+	}
+
+	return !diffs.Empty(), nil
+}
+
+func (atcConfig ATCConfig) showDiffs(diffs ConfigDiffs, secrets Secrets) error {
+	diffs, err := diffs.Redact(secrets)
+	if err != nil {
+		return err
+	}
+
+	switch atcConfig.DiffFormat {
+	case "json":
+		return diffs.RenderJSON(os.Stdout)
+	case "patch":
+		diffs.RenderPatch(os.Stdout)
+	default:
+		diffs.Render(os.Stdout)
+	}
+
+	return nil
+}
+
+// newConfig returns the evaluated config, along with the values of any
+// secret variables that were templated into it.
+func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, templateVariablesFiles []flaghelpers.PathFlag, templateVariables template.Variables) (atc.Config, Secrets) {
 	configFile, err := ioutil.ReadFile(string(configPath))
 	if err != nil {
 		displayhelpers.FailWithErrorf("could not read config file", err)
 	}
 
-	var resultVars template.Variables
+	resultVars := template.Variables{}
 
 	for _, path := range templateVariablesFiles {
 		fileVars, templateErr := template.LoadVariablesFromFile(string(path))
@@ -87,7 +135,21 @@ func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, templateVa
 
 	resultVars = resultVars.Merge(templateVariables)
 
-	configFile, err = template.Evaluate(configFile, resultVars)
+	// providers are only asked for what files and flags leave unbound, and
+	// what they give is always secret
+	providedVars, err := template.ResolveVariables(configFile, atcConfig.VarsProviders, resultVars)
+	if err != nil {
+		displayhelpers.FailWithErrorf("failed to resolve variables from providers", err)
+	}
+
+	secretVars := append([]string{}, atcConfig.SecretVars...)
+	for name := range providedVars {
+		secretVars = append(secretVars, name)
+	}
+
+	resultVars = providedVars.Merge(resultVars)
+
+	configFile, substitutions, err := template.EvaluateTracked(configFile, resultVars)
 	if err != nil {
 		displayhelpers.FailWithErrorf("failed to evaluate variables into template", err)
 	}
@@ -98,15 +160,15 @@ func (atcConfig ATCConfig) newConfig(configPath flaghelpers.PathFlag, templateVa
 		displayhelpers.FailWithErrorf("failed to parse configuration file", err)
 	}
 
-	return newConfig
+	return newConfig, SecretValues(substitutions, secretVars)
 }
 
-func (atcConfig ATCConfig) showWarnings(warnings []concourse.ConfigWarning) {
+func (atcConfig ATCConfig) showWarnings(warnings []concourse.ConfigWarning, secrets Secrets) {
 	fmt.Fprintln(os.Stderr, "")
 	displayhelpers.Warn("DEPRECATION WARNING:")
 
 	for _, warning := range warnings {
-		fmt.Fprintf(os.Stderr, "  - %s\n", warning.Message)
+		fmt.Fprintf(os.Stderr, "  - %s\n", secrets.RedactString(warning.Message))
 	}
 
 	fmt.Fprintln(os.Stderr, "")
@@ -138,43 +200,3 @@ func (atcConfig ATCConfig) showHelpfulMessage(created bool, updated bool) {
 		panic("Something really went wrong!")
 	}
 }
-
-func diff(existingConfig atc.Config, newConfig atc.Config) {
-	indent := gexec.NewPrefixedWriter("  ", os.Stdout)
-
-	groupDiffs := diffIndices(GroupIndex(existingConfig.Groups), GroupIndex(newConfig.Groups))
-	if len(groupDiffs) > 0 {
-		fmt.Println("groups:")
-
-		for _, diff := range groupDiffs {
-			diff.Render(indent, "group")
-		}
-	}
-
-	resourceDiffs := diffIndices(ResourceIndex(existingConfig.Resources), ResourceIndex(newConfig.Resources))
-	if len(resourceDiffs) > 0 {
-		fmt.Println("resources:")
-
-		for _, diff := range resourceDiffs {
-			diff.Render(indent, "resource")
-		}
-	}
-
-	resourceTypeDiffs := diffIndices(ResourceTypeIndex(existingConfig.ResourceTypes), ResourceTypeIndex(newConfig.ResourceTypes))
-	if len(resourceTypeDiffs) > 0 {
-		fmt.Println("resource types:")
-
-		for _, diff := range resourceTypeDiffs {
-			diff.Render(indent, "resource type")
-		}
-	}
-
-	jobDiffs := diffIndices(JobIndex(existingConfig.Jobs), JobIndex(newConfig.Jobs))
-	if len(jobDiffs) > 0 {
-		fmt.Println("jobs:")
-
-		for _, diff := range jobDiffs {
-			diff.Render(indent, "job")
-		}
-	}
-}
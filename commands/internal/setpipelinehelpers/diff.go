@@ -2,6 +2,7 @@ package setpipelinehelpers
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -24,22 +25,324 @@ type Diffs []Diff
 type Diff struct {
 	Before interface{}
 	After  interface{}
+
+	unredacted *Diff
+	secrets    Secrets
+}
+
+// ConfigDiffs are the differences between two pipeline configs, by section.
+// Other has the changes to any top-level fields outside of those sections.
+type ConfigDiffs struct {
+	Groups        Diffs
+	Resources     Diffs
+	ResourceTypes Diffs
+	Jobs          Diffs
+	Other         []Change
+
+	otherBefore map[string]interface{}
+	otherAfter  map[string]interface{}
+}
+
+func DiffConfigs(existingConfig atc.Config, newConfig atc.Config) ConfigDiffs {
+	otherBefore := otherFields(existingConfig)
+	otherAfter := otherFields(newConfig)
+
+	return ConfigDiffs{
+		Groups:        diffIndices(GroupIndex(existingConfig.Groups), GroupIndex(newConfig.Groups)),
+		Resources:     diffIndices(ResourceIndex(existingConfig.Resources), ResourceIndex(newConfig.Resources)),
+		ResourceTypes: diffIndices(ResourceTypeIndex(existingConfig.ResourceTypes), ResourceTypeIndex(newConfig.ResourceTypes)),
+		Jobs:          diffIndices(JobIndex(existingConfig.Jobs), JobIndex(newConfig.Jobs)),
+		Other:         diffValues("", otherBefore, otherAfter),
+
+		otherBefore: otherBefore,
+		otherAfter:  otherAfter,
+	}
+}
+
+func (diffs ConfigDiffs) Empty() bool {
+	return len(diffs.Groups) == 0 &&
+		len(diffs.Resources) == 0 &&
+		len(diffs.ResourceTypes) == 0 &&
+		len(diffs.Jobs) == 0 &&
+		len(diffs.Other) == 0
+}
+
+// otherFields returns the top-level fields of a config that aren't compared
+// by index, so that fields added to the config are still shown when they
+// change.
+func otherFields(config atc.Config) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	decoded, err := jsonCompatible(config)
+	if err != nil {
+		return fields
+	}
+
+	object, ok := decoded.(map[string]interface{})
+	if !ok {
+		return fields
+	}
+
+	for key, value := range object {
+		switch key {
+		case "groups", "resources", "resource_types", "jobs":
+		default:
+			fields[key] = value
+		}
+	}
+
+	return fields
+}
+
+type configSection struct {
+	title string
+	label string
+	key   string
+	diffs Diffs
+}
+
+func (diffs ConfigDiffs) sections() []configSection {
+	return []configSection{
+		{title: "groups", label: "group", key: "groups", diffs: diffs.Groups},
+		{title: "resources", label: "resource", key: "resources", diffs: diffs.Resources},
+		{title: "resource types", label: "resource type", key: "resource_types", diffs: diffs.ResourceTypes},
+		{title: "jobs", label: "job", key: "jobs", diffs: diffs.Jobs},
+	}
+}
+
+// Render writes the diffs as coloured YAML, grouped by section.
+func (diffs ConfigDiffs) Render(to io.Writer) {
+	indent := gexec.NewPrefixedWriter("  ", to)
+
+	for _, section := range diffs.sections() {
+		if len(section.diffs) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(to, "%s:\n", section.title)
+
+		for _, diff := range section.diffs {
+			diff.Render(indent, section.label, section.key)
+		}
+	}
+
+	if len(diffs.Other) > 0 {
+		fmt.Fprintln(to, "other fields:")
+
+		for _, change := range diffs.Other {
+			change.Render(indent)
+		}
+	}
+}
+
+// RenderJSON writes the diffs as a JSON object with a list of changes for
+// each section.
+func (diffs ConfigDiffs) RenderJSON(to io.Writer) error {
+	document := map[string]interface{}{}
+
+	for _, section := range diffs.sections() {
+		changes := []interface{}{}
+
+		for _, diff := range section.diffs {
+			change, err := diff.jsonChange()
+			if err != nil {
+				return err
+			}
+
+			changes = append(changes, change)
+		}
+
+		document[section.key] = changes
+	}
+
+	document["other"] = jsonChanges(diffs.Other)
+
+	payload, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(to, "%s\n", payload)
+	return err
+}
+
+// RenderPatch writes the diffs as a unified diff of each object's YAML, with
+// paths like 'jobs/some-job', so that it can be posted for review.
+func (diffs ConfigDiffs) RenderPatch(to io.Writer) {
+	for _, section := range diffs.sections() {
+		for _, diff := range section.diffs {
+			diff.renderPatch(to, section.key)
+		}
+	}
+
+	if len(diffs.Other) > 0 {
+		payloadA, _ := yaml.Marshal(diffs.otherBefore)
+		payloadB, _ := yaml.Marshal(diffs.otherAfter)
+
+		fmt.Fprintln(to, "--- a/config")
+		fmt.Fprintln(to, "+++ b/config")
+
+		renderHunks(to, patchLines(string(payloadA)), patchLines(string(payloadB)))
+	}
+}
+
+// Action is how the object has changed: "added", "removed", "renamed" or
+// "changed".
+func (diff Diff) Action() string {
+	// a redacted name may hide a rename
+	if diff.unredacted != nil {
+		return diff.unredacted.Action()
+	}
+
+	if diff.Before != nil && diff.After != nil {
+		if name(diff.Before) != name(diff.After) {
+			return "renamed"
+		}
+
+		return "changed"
+	} else if diff.Before != nil {
+		return "removed"
+	} else {
+		return "added"
+	}
+}
+
+// Name is the object's name; for a renamed object, its new name.
+func (diff Diff) Name() string {
+	if diff.After != nil {
+		return name(diff.After)
+	}
+
+	return name(diff.Before)
+}
+
+// Changes are the paths that differ within a changed object, prefixed with
+// the given path.
+func (diff Diff) Changes(path string) []Change {
+	if diff.unredacted != nil {
+		return diff.secrets.redactChanges(diff.unredacted.Changes(path))
+	}
+
+	before, err := jsonCompatible(diff.Before)
+	if err != nil {
+		return []Change{{Path: path, Action: "changed", Before: diff.Before, After: diff.After}}
+	}
+
+	after, err := jsonCompatible(diff.After)
+	if err != nil {
+		return []Change{{Path: path, Action: "changed", Before: diff.Before, After: diff.After}}
+	}
+
+	return diffValues(path, before, after)
+}
+
+func (diff Diff) jsonChange() (interface{}, error) {
+	change := map[string]interface{}{
+		"name":   diff.Name(),
+		"action": diff.Action(),
+	}
+
+	if diff.Before != nil {
+		before, err := jsonCompatible(diff.Before)
+		if err != nil {
+			return nil, err
+		}
+
+		change["before"] = before
+	}
+
+	if diff.After != nil {
+		after, err := jsonCompatible(diff.After)
+		if err != nil {
+			return nil, err
+		}
+
+		change["after"] = after
+	}
+
+	if diff.Action() == "renamed" {
+		change["previous_name"] = name(diff.Before)
+	}
+
+	if diff.Action() == "changed" {
+		change["changes"] = jsonChanges(diff.Changes(""))
+	}
+
+	return change, nil
+}
+
+func jsonChanges(changes []Change) []interface{} {
+	encoded := []interface{}{}
+
+	for _, change := range changes {
+		entry := map[string]interface{}{
+			"path":   change.Path,
+			"action": change.Action,
+		}
+
+		switch change.Action {
+		case "added":
+			entry["after"] = change.After
+		case "removed":
+			entry["before"] = change.Before
+		case "moved":
+			entry["from"] = change.From
+		default:
+			entry["before"] = change.Before
+			entry["after"] = change.After
+		}
+
+		encoded = append(encoded, entry)
+	}
+
+	return encoded
+}
+
+func (diff Diff) renderPatch(to io.Writer, section string) {
+	var payloadA, payloadB []byte
+
+	oldPath := "/dev/null"
+	if diff.Before != nil {
+		payloadA, _ = yaml.Marshal(diff.Before)
+		oldPath = "a/" + section + "/" + name(diff.Before)
+	}
+
+	newPath := "/dev/null"
+	if diff.After != nil {
+		payloadB, _ = yaml.Marshal(diff.After)
+		newPath = "b/" + section + "/" + name(diff.After)
+	}
+
+	fmt.Fprintf(to, "--- %s\n", oldPath)
+	fmt.Fprintf(to, "+++ %s\n", newPath)
+
+	renderHunks(to, patchLines(string(payloadA)), patchLines(string(payloadB)))
 }
 
 func name(v interface{}) string {
+	// redacted objects are kept as they were decoded
+	if object, ok := v.(yaml.MapSlice); ok {
+		name, _ := lookupItem(object, "name").(string)
+		return name
+	}
+
 	return reflect.ValueOf(v).FieldByName("Name").String()
 }
 
-func (diff Diff) Render(to io.Writer, label string) {
+// Render writes the diff of an object in the given section of the config. A
+// changed object shows only the paths within it that changed, such as
+// 'jobs.some-job.plan[3].params.env'.
+func (diff Diff) Render(to io.Writer, label string, section string) {
 	indent := gexec.NewPrefixedWriter("  ", to)
 
-	if diff.Before != nil && diff.After != nil {
+	if diff.Action() == "renamed" {
+		fmt.Fprintf(to, ansi.Color("%s %s has been renamed to %s", "yellow")+"\n", label, name(diff.Before), name(diff.After))
+	} else if diff.Before != nil && diff.After != nil {
 		fmt.Fprintf(to, ansi.Color("%s %s has changed:", "yellow")+"\n", label, name(diff.Before))
 
-		payloadA, _ := yaml.Marshal(diff.Before)
-		payloadB, _ := yaml.Marshal(diff.After)
-
-		renderDiff(indent, string(payloadA), string(payloadB))
+		for _, change := range diff.Changes(joinPath(section, name(diff.After))) {
+			change.Render(indent)
+		}
 	} else if diff.Before != nil {
 		fmt.Fprintf(to, ansi.Color("%s %s has been removed:", "yellow")+"\n", label, name(diff.Before))
 
@@ -55,6 +358,35 @@ func (diff Diff) Render(to io.Writer, label string) {
 	}
 }
 
+// Render writes the change as the path that changed followed by its old
+// and new values.
+func (change Change) Render(to io.Writer) {
+	indent := gexec.NewPrefixedWriter("  ", to)
+
+	switch change.Action {
+	case "added":
+		fmt.Fprintf(to, "%s has been added:\n", change.Path)
+		renderValue(indent, change.After, "green")
+	case "removed":
+		fmt.Fprintf(to, "%s has been removed:\n", change.Path)
+		renderValue(indent, change.Before, "red")
+	case "moved":
+		fmt.Fprintf(to, "%s has moved to %s\n", change.From, change.Path)
+	default:
+		fmt.Fprintf(to, "%s has changed:\n", change.Path)
+		renderValue(indent, change.Before, "red")
+		renderValue(indent, change.After, "green")
+	}
+}
+
+func renderValue(to io.Writer, value interface{}, color string) {
+	payload, _ := yaml.Marshal(value)
+
+	for _, line := range patchLines(string(payload)) {
+		fmt.Fprintf(to, "%s\n", ansi.Color(line, color))
+	}
+}
+
 type GroupIndex atc.GroupConfigs
 
 func (index GroupIndex) Slice() []interface{} {
@@ -147,7 +479,65 @@ func diffIndices(oldIndex Index, newIndex Index) Diffs {
 		}
 	}
 
-	return diffs
+	return pairRenames(diffs)
+}
+
+// pairRenames combines a removed and an added object that are identical but
+// for their name into one diff, so that renaming e.g. a job doesn't show its
+// whole config twice.
+func pairRenames(diffs Diffs) Diffs {
+	paired := Diffs{}
+	used := make([]bool, len(diffs))
+
+	for i, removed := range diffs {
+		if used[i] || removed.Action() != "removed" {
+			continue
+		}
+
+		for j, added := range diffs {
+			if used[j] || added.Action() != "added" {
+				continue
+			}
+
+			if sameButForName(removed.Before, added.After) {
+				diffs[i].After = added.After
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	for i, diff := range diffs {
+		if !used[i] {
+			paired = append(paired, diff)
+		}
+	}
+
+	return paired
+}
+
+func sameButForName(a, b interface{}) bool {
+	decodedA, err := jsonCompatible(a)
+	if err != nil {
+		return false
+	}
+
+	decodedB, err := jsonCompatible(b)
+	if err != nil {
+		return false
+	}
+
+	objectA, ok := decodedA.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	objectB, ok := decodedB.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	return reflect.DeepEqual(withoutKey(objectA, "name"), withoutKey(objectB, "name"))
 }
 
 func renderDiff(to io.Writer, a, b string) {
@@ -167,6 +557,131 @@ func renderDiff(to io.Writer, a, b string) {
 	}
 }
 
+const patchContext = 3
+
+func patchLines(payload string) []string {
+	if payload == "" {
+		return []string{}
+	}
+
+	return strings.Split(strings.TrimSuffix(payload, "\n"), "\n")
+}
+
+func renderHunks(to io.Writer, a, b []string) {
+	records := difflib.Diff(a, b)
+
+	// the number of lines of each side before each record
+	oldLines := make([]int, len(records)+1)
+	newLines := make([]int, len(records)+1)
+
+	changes := []int{}
+
+	for i, record := range records {
+		oldLines[i+1] = oldLines[i]
+		newLines[i+1] = newLines[i]
+
+		if record.Delta != difflib.RightOnly {
+			oldLines[i+1]++
+		}
+
+		if record.Delta != difflib.LeftOnly {
+			newLines[i+1]++
+		}
+
+		if record.Delta != difflib.Common {
+			changes = append(changes, i)
+		}
+	}
+
+	for len(changes) > 0 {
+		// gather changes that are close enough to share their context
+		last := 0
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*patchContext+1 {
+			last++
+		}
+
+		start := changes[0] - patchContext
+		if start < 0 {
+			start = 0
+		}
+
+		end := changes[last] + patchContext + 1
+		if end > len(records) {
+			end = len(records)
+		}
+
+		fmt.Fprintf(
+			to,
+			"@@ -%s +%s @@\n",
+			hunkRange(oldLines[start], oldLines[end]-oldLines[start]),
+			hunkRange(newLines[start], newLines[end]-newLines[start]),
+		)
+
+		for _, record := range records[start:end] {
+			switch record.Delta {
+			case difflib.RightOnly:
+				fmt.Fprintf(to, "+%s\n", record.Payload)
+			case difflib.LeftOnly:
+				fmt.Fprintf(to, "-%s\n", record.Payload)
+			case difflib.Common:
+				fmt.Fprintf(to, " %s\n", record.Payload)
+			}
+		}
+
+		changes = changes[last+1:]
+	}
+}
+
+func hunkRange(before int, count int) string {
+	if count == 0 {
+		// an empty range refers to the line before it
+		return fmt.Sprintf("%d,0", before)
+	}
+
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// jsonCompatible converts an object into what its YAML form decodes into,
+// with string keys, since YAML decodes nested objects into maps that can't be
+// encoded as JSON.
+func jsonCompatible(v interface{}) (interface{}, error) {
+	payload, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	err = yaml.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return stringKeys(decoded), nil
+}
+
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, val := range v {
+			converted[fmt.Sprintf("%v", key)] = stringKeys(val)
+		}
+
+		return converted
+
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, val := range v {
+			converted[i] = stringKeys(val)
+		}
+
+		return converted
+
+	default:
+		return v
+	}
+}
+
 func practicallyDifferent(a, b interface{}) bool {
 	if reflect.DeepEqual(a, b) {
 		return false
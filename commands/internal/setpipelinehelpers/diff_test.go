@@ -0,0 +1,271 @@
+package setpipelinehelpers_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/concourse/atc"
+	. "github.com/concourse/fly/commands/internal/setpipelinehelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+	var existingConfig atc.Config
+	var newConfig atc.Config
+
+	BeforeEach(func() {
+		existingConfig = atc.Config{
+			Resources: atc.ResourceConfigs{
+				{
+					Name:   "some-resource",
+					Type:   "git",
+					Source: atc.Source{"uri": "https://example.com/old"},
+				},
+				{
+					Name: "some-removed-resource",
+					Type: "git",
+				},
+			},
+			Jobs: atc.JobConfigs{
+				{Name: "some-unchanged-job"},
+			},
+		}
+
+		newConfig = atc.Config{
+			Resources: atc.ResourceConfigs{
+				{
+					Name:   "some-resource",
+					Type:   "git",
+					Source: atc.Source{"uri": "https://example.com/new"},
+				},
+			},
+			Jobs: atc.JobConfigs{
+				{Name: "some-unchanged-job"},
+				{Name: "some-new-job", Serial: true},
+			},
+		}
+	})
+
+	Describe("DiffConfigs", func() {
+		It("finds the added, removed and changed objects", func() {
+			diffs := DiffConfigs(existingConfig, newConfig)
+
+			Expect(diffs.Empty()).To(BeFalse())
+			Expect(diffs.Groups).To(BeEmpty())
+			Expect(diffs.ResourceTypes).To(BeEmpty())
+
+			Expect(diffs.Resources).To(HaveLen(2))
+			Expect(diffs.Resources[0].Name()).To(Equal("some-resource"))
+			Expect(diffs.Resources[0].Action()).To(Equal("changed"))
+			Expect(diffs.Resources[1].Name()).To(Equal("some-removed-resource"))
+			Expect(diffs.Resources[1].Action()).To(Equal("removed"))
+
+			Expect(diffs.Jobs).To(HaveLen(1))
+			Expect(diffs.Jobs[0].Name()).To(Equal("some-new-job"))
+			Expect(diffs.Jobs[0].Action()).To(Equal("added"))
+		})
+
+		It("is empty when nothing has changed", func() {
+			Expect(DiffConfigs(existingConfig, existingConfig).Empty()).To(BeTrue())
+		})
+	})
+
+	Describe("RenderJSON", func() {
+		It("lists the changes in each section", func() {
+			buf := new(bytes.Buffer)
+
+			err := DiffConfigs(existingConfig, newConfig).RenderJSON(buf)
+			Expect(err).NotTo(HaveOccurred())
+
+			var document map[string][]map[string]interface{}
+			err = json.Unmarshal(buf.Bytes(), &document)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(document["groups"]).To(BeEmpty())
+			Expect(document["resource_types"]).To(BeEmpty())
+
+			Expect(document["resources"]).To(HaveLen(2))
+			Expect(document["resources"][0]["name"]).To(Equal("some-resource"))
+			Expect(document["resources"][0]["action"]).To(Equal("changed"))
+			Expect(document["resources"][0]["before"]).To(HaveKeyWithValue("source", map[string]interface{}{"uri": "https://example.com/old"}))
+			Expect(document["resources"][0]["after"]).To(HaveKeyWithValue("source", map[string]interface{}{"uri": "https://example.com/new"}))
+
+			Expect(document["resources"][1]["action"]).To(Equal("removed"))
+			Expect(document["resources"][1]).NotTo(HaveKey("after"))
+
+			Expect(document["jobs"]).To(HaveLen(1))
+			Expect(document["jobs"][0]["action"]).To(Equal("added"))
+			Expect(document["jobs"][0]).NotTo(HaveKey("before"))
+			Expect(document["jobs"][0]["after"]).To(HaveKeyWithValue("serial", true))
+		})
+
+		Context("when a source has nested objects", func() {
+			BeforeEach(func() {
+				newConfig.Resources[0].Source = atc.Source{
+					"uri": map[interface{}]interface{}{"host": "example.com"},
+				}
+			})
+
+			It("can still encode them", func() {
+				buf := new(bytes.Buffer)
+
+				err := DiffConfigs(existingConfig, newConfig).RenderJSON(buf)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buf.String()).To(ContainSubstring(`"host": "example.com"`))
+			})
+		})
+	})
+
+	Describe("RenderPatch", func() {
+		It("writes a unified diff of each object", func() {
+			buf := new(bytes.Buffer)
+
+			DiffConfigs(existingConfig, newConfig).RenderPatch(buf)
+
+			Expect(buf.String()).To(ContainSubstring(
+				"--- a/resources/some-resource\n" +
+					"+++ b/resources/some-resource\n" +
+					"@@ -1,",
+			))
+			Expect(buf.String()).To(ContainSubstring(
+				"-  uri: https://example.com/old\n" +
+					"+  uri: https://example.com/new\n",
+			))
+
+			Expect(buf.String()).To(ContainSubstring(
+				"--- a/resources/some-removed-resource\n" +
+					"+++ /dev/null\n" +
+					"@@ -1,",
+			))
+			Expect(buf.String()).To(ContainSubstring(
+				"-name: some-removed-resource\n" +
+					"-type: git\n",
+			))
+
+			Expect(buf.String()).To(ContainSubstring(
+				"--- /dev/null\n" +
+					"+++ b/jobs/some-new-job\n" +
+					"@@ -0,0 +1,",
+			))
+			Expect(buf.String()).To(ContainSubstring("+name: some-new-job\n"))
+			Expect(buf.String()).To(ContainSubstring("+serial: true\n"))
+			Expect(buf.String()).NotTo(ContainSubstring(
+				"-name: some-new-job\n",
+			))
+
+			Expect(buf.String()).NotTo(ContainSubstring("some-unchanged-job"))
+		})
+	})
+
+	Describe("Changes", func() {
+		var before atc.JobConfig
+		var after atc.JobConfig
+
+		BeforeEach(func() {
+			before = atc.JobConfig{
+				Name: "deploy",
+				Plan: atc.PlanSequence{
+					{Get: "repo"},
+					{Task: "build", TaskConfigPath: "repo/ci/build.yml"},
+					{Put: "app", Params: atc.Params{"env": "staging"}},
+				},
+			}
+
+			after = atc.JobConfig{
+				Name: "deploy",
+				Plan: atc.PlanSequence{
+					{Get: "repo"},
+					{Task: "build", TaskConfigPath: "repo/ci/build.yml"},
+					{Put: "app", Params: atc.Params{"env": "production"}},
+				},
+			}
+		})
+
+		It("shows only the paths that changed", func() {
+			changes := Diff{Before: before, After: after}.Changes("jobs.deploy")
+
+			Expect(changes).To(Equal([]Change{
+				{
+					Path:   "jobs.deploy.plan[2].params.env",
+					Action: "changed",
+					Before: "staging",
+					After:  "production",
+				},
+			}))
+		})
+
+		Context("when a step is inserted", func() {
+			BeforeEach(func() {
+				after.Plan = append(atc.PlanSequence{{Task: "lint"}}, after.Plan...)
+			})
+
+			It("shows the new step without treating the later steps as changed", func() {
+				changes := Diff{Before: before, After: after}.Changes("jobs.deploy")
+
+				Expect(changes).To(HaveLen(2))
+				Expect(changes[0].Path).To(Equal("jobs.deploy.plan[0]"))
+				Expect(changes[0].Action).To(Equal("added"))
+				Expect(changes[1].Path).To(Equal("jobs.deploy.plan[3].params.env"))
+				Expect(changes[1].Action).To(Equal("changed"))
+			})
+		})
+
+		Context("when steps are reordered", func() {
+			BeforeEach(func() {
+				after.Plan = atc.PlanSequence{after.Plan[1], after.Plan[0], after.Plan[2]}
+				after.Plan[2].Params = atc.Params{"env": "staging"}
+			})
+
+			It("shows the step that moved", func() {
+				changes := Diff{Before: before, After: after}.Changes("jobs.deploy")
+
+				Expect(changes).To(Equal([]Change{
+					{
+						Path:   "jobs.deploy.plan[1]",
+						Action: "moved",
+						From:   "jobs.deploy.plan[0]",
+					},
+				}))
+			})
+		})
+
+		Context("when a step is renamed", func() {
+			BeforeEach(func() {
+				after.Plan[1].Task = "compile"
+				after.Plan[2].Params = atc.Params{"env": "staging"}
+			})
+
+			It("shows the new name rather than a removed and an added step", func() {
+				changes := Diff{Before: before, After: after}.Changes("jobs.deploy")
+
+				Expect(changes).To(Equal([]Change{
+					{
+						Path:   "jobs.deploy.plan[1].task",
+						Action: "changed",
+						Before: "build",
+						After:  "compile",
+					},
+				}))
+			})
+		})
+	})
+
+	Context("when an object is renamed", func() {
+		BeforeEach(func() {
+			newConfig.Resources = append(newConfig.Resources, atc.ResourceConfig{
+				Name: "some-renamed-resource",
+				Type: "git",
+			})
+		})
+
+		It("pairs the removed and added objects", func() {
+			diffs := DiffConfigs(existingConfig, newConfig)
+
+			Expect(diffs.Resources).To(HaveLen(2))
+			Expect(diffs.Resources[1].Action()).To(Equal("renamed"))
+			Expect(diffs.Resources[1].Name()).To(Equal("some-renamed-resource"))
+		})
+	})
+})
@@ -0,0 +1,394 @@
+package setpipelinehelpers
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/concourse/fly/template"
+	"gopkg.in/yaml.v2"
+)
+
+const redacted = "((redacted))"
+
+// Secrets are values that must not be shown, such as credentials templated
+// into the config.
+type Secrets []string
+
+// SecretValues returns the values of the templated variables whose names
+// match any of the given patterns, which may be globs like '*_password'.
+func SecretValues(substitutions []template.Substitution, patterns []string) Secrets {
+	secrets := Secrets{}
+	seen := map[string]bool{}
+
+	for _, substitution := range substitutions {
+		if !matchesAny(patterns, substitution.Name) {
+			continue
+		}
+
+		for _, value := range stringsIn(substitution.Value) {
+			if value != "" && !seen[value] {
+				secrets = append(secrets, value)
+				seen[value] = true
+			}
+		}
+	}
+
+	// replace longer secrets first, so that a secret containing another is
+	// hidden entirely
+	sort.Sort(longestFirst(secrets))
+
+	return secrets
+}
+
+// matchesAny returns whether the name, or the name of the variable a dotted
+// path like 'aws.secret_key' starts with, matches any of the patterns.
+func matchesAny(patterns []string, name string) bool {
+	segments := strings.Split(name, ".")
+
+	for i := range segments {
+		prefix := strings.Join(segments[:i+1], ".")
+
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, prefix); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// stringsIn returns the text of each scalar within a variable's value, which
+// may be a map or a list of credentials. Numbers and booleans are included,
+// since a secret like a PIN may have been templated in as one.
+func stringsIn(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+
+	case string:
+		return []string{v}
+
+	case map[string]interface{}:
+		values := []string{}
+		for _, val := range v {
+			values = append(values, stringsIn(val)...)
+		}
+
+		return values
+
+	case []interface{}:
+		values := []string{}
+		for _, val := range v {
+			values = append(values, stringsIn(val)...)
+		}
+
+		return values
+
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+type longestFirst Secrets
+
+func (s longestFirst) Len() int           { return len(s) }
+func (s longestFirst) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s longestFirst) Less(i, j int) bool { return len(s[i]) > len(s[j]) }
+
+func (secrets Secrets) RedactString(s string) string {
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, redacted, -1)
+	}
+
+	return s
+}
+
+// Redact returns the diffs with every secret hidden from the objects in them.
+// They're still compared as they were, so a changed secret shows up as a
+// change that can't be seen.
+func (diffs ConfigDiffs) Redact(secrets Secrets) (ConfigDiffs, error) {
+	if len(secrets) == 0 {
+		return diffs, nil
+	}
+
+	var err error
+
+	for _, section := range []*Diffs{&diffs.Groups, &diffs.Resources, &diffs.ResourceTypes, &diffs.Jobs} {
+		*section, err = section.redact(secrets)
+		if err != nil {
+			return ConfigDiffs{}, err
+		}
+	}
+
+	diffs.Other = secrets.redactChanges(diffs.Other)
+	diffs.otherBefore, _ = secrets.redactValue(secrets.maskCounterparts(diffs.otherBefore, diffs.otherAfter)).(map[string]interface{})
+	diffs.otherAfter, _ = secrets.redactValue(diffs.otherAfter).(map[string]interface{})
+
+	return diffs, nil
+}
+
+// redact hides the secrets from each object, which is then rendered from its
+// decoded form; putting a hidden number or boolean back into the config's
+// types would fail.
+func (diffs Diffs) redact(secrets Secrets) (Diffs, error) {
+	redactedDiffs := make(Diffs, len(diffs))
+
+	for i, diff := range diffs {
+		// the paths that changed are found from the original objects, since
+		// redacting them may have hidden the change
+		unredacted := diff
+		diff.unredacted = &unredacted
+		diff.secrets = secrets
+
+		var before, after interface{}
+		var err error
+
+		if diff.Before != nil {
+			before, err = decodeObject(diff.Before)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if diff.After != nil {
+			after, err = decodeObject(diff.After)
+			if err != nil {
+				return nil, err
+			}
+
+			before = secrets.maskCounterparts(before, after)
+		}
+
+		if diff.Before != nil {
+			diff.Before = secrets.redactValue(before)
+		}
+
+		if diff.After != nil {
+			diff.After = secrets.redactValue(after)
+		}
+
+		redactedDiffs[i] = diff
+	}
+
+	return redactedDiffs, nil
+}
+
+// decodeObject converts a config object into what its YAML decodes into,
+// keeping the order of its fields so that it's rendered just as the object
+// would be.
+func decodeObject(object interface{}) (interface{}, error) {
+	payload, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded orderedValue
+	err = yaml.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded.value, nil
+}
+
+// orderedValue decodes YAML objects into yaml.MapSlice, at any depth, rather
+// than into maps, which lose the order of their keys.
+type orderedValue struct {
+	value interface{}
+}
+
+func (ordered *orderedValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var decoded interface{}
+	err := unmarshal(&decoded)
+	if err != nil {
+		return err
+	}
+
+	switch decoded.(type) {
+	case map[interface{}]interface{}:
+		var keys yaml.MapSlice
+		err := unmarshal(&keys)
+		if err != nil {
+			return err
+		}
+
+		var values map[interface{}]orderedValue
+		err = unmarshal(&values)
+		if err != nil {
+			return err
+		}
+
+		object := make(yaml.MapSlice, len(keys))
+		for i, item := range keys {
+			object[i] = yaml.MapItem{Key: item.Key, Value: values[item.Key].value}
+		}
+
+		ordered.value = object
+
+	case []interface{}:
+		var values []orderedValue
+		err := unmarshal(&values)
+		if err != nil {
+			return err
+		}
+
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			list[i] = value.value
+		}
+
+		ordered.value = list
+
+	default:
+		ordered.value = decoded
+	}
+
+	return nil
+}
+
+// maskCounterparts hides the old values of whatever now holds a secret. Only
+// the secrets being templated in now are known, but the value they replace
+// is likely just as secret.
+func (secrets Secrets) maskCounterparts(before, after interface{}) interface{} {
+	switch a := after.(type) {
+	case yaml.MapSlice:
+		if b, ok := before.(yaml.MapSlice); ok {
+			masked := make(yaml.MapSlice, len(b))
+			for i, item := range b {
+				masked[i] = yaml.MapItem{Key: item.Key, Value: secrets.maskCounterparts(item.Value, lookupItem(a, item.Key))}
+			}
+
+			return masked
+		}
+
+	case map[string]interface{}:
+		if b, ok := before.(map[string]interface{}); ok {
+			masked := map[string]interface{}{}
+			for key, val := range b {
+				masked[key] = secrets.maskCounterparts(val, a[key])
+			}
+
+			return masked
+		}
+
+	case []interface{}:
+		if b, ok := before.([]interface{}); ok {
+			masked := make([]interface{}, len(b))
+			for i, val := range b {
+				if i < len(a) {
+					masked[i] = secrets.maskCounterparts(val, a[i])
+				} else {
+					masked[i] = val
+				}
+			}
+
+			return masked
+		}
+
+	default:
+		if isScalar(a) && isScalar(before) && secrets.redactsScalar(a) {
+			return redacted
+		}
+	}
+
+	return before
+}
+
+func lookupItem(object yaml.MapSlice, key interface{}) interface{} {
+	for _, item := range object {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+
+	return nil
+}
+
+// redactChanges hides the secrets from the values that changed. Paths are
+// left as they are, as they're made of the config's keys and list indices,
+// which a secret short enough, like a PIN, could otherwise be found in.
+func (secrets Secrets) redactChanges(changes []Change) []Change {
+	redactedChanges := make([]Change, len(changes))
+
+	for i, change := range changes {
+		change.Before = secrets.redactValue(secrets.maskCounterparts(change.Before, change.After))
+		change.After = secrets.redactValue(change.After)
+		redactedChanges[i] = change
+	}
+
+	return redactedChanges
+}
+
+func (secrets Secrets) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return secrets.RedactString(v)
+
+	// keys are left as they are, just like the paths of changes
+	case yaml.MapSlice:
+		redactedObject := make(yaml.MapSlice, len(v))
+		for i, item := range v {
+			redactedObject[i] = yaml.MapItem{Key: item.Key, Value: secrets.redactValue(item.Value)}
+		}
+
+		return redactedObject
+
+	case map[string]interface{}:
+		redactedMap := map[string]interface{}{}
+		for key, val := range v {
+			redactedMap[key] = secrets.redactValue(val)
+		}
+
+		return redactedMap
+
+	case []interface{}:
+		redactedList := make([]interface{}, len(v))
+		for i, val := range v {
+			redactedList[i] = secrets.redactValue(val)
+		}
+
+		return redactedList
+
+	case nil:
+		return nil
+
+	default:
+		// a number or a boolean can't be partly hidden, so it's hidden whole
+		if secrets.redactsScalar(v) {
+			return redacted
+		}
+
+		return value
+	}
+}
+
+// redactsScalar returns whether a string contains any secret, or whether the
+// text of a number or a boolean is a secret. A number merely containing a
+// secret is left alone, as it's more likely to be e.g. a count of attempts
+// that happens to share digits with a secret.
+func (secrets Secrets) redactsScalar(value interface{}) bool {
+	if text, ok := value.(string); ok {
+		return secrets.RedactString(text) != text
+	}
+
+	text := fmt.Sprint(value)
+	for _, secret := range secrets {
+		if text == secret {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case nil, yaml.MapSlice, map[string]interface{}, []interface{}:
+		return false
+	default:
+		return true
+	}
+}
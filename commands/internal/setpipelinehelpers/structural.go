@@ -0,0 +1,282 @@
+package setpipelinehelpers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// A Change is a difference at one path within a config object, such as
+// 'plan[3].params.env'. A value that moved within a list has From set to
+// where it was before; any changes within it are listed separately.
+type Change struct {
+	Path   string
+	Action string
+	From   string
+	Before interface{}
+	After  interface{}
+}
+
+// identityKeys are the fields that name an element of a list, in the order
+// they're looked for; plan steps are named by what they get, put or run.
+var identityKeys = []string{"name", "get", "put", "task"}
+
+// diffValues compares two values as decoded from YAML, walking into objects
+// and lists so that only the paths that differ are reported.
+func diffValues(path string, before, after interface{}) []Change {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		return diffMaps(path, beforeMap, afterMap)
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList {
+		return diffLists(path, beforeList, afterList)
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	return []Change{{Path: path, Action: "changed", Before: before, After: after}}
+}
+
+func diffMaps(path string, before, after map[string]interface{}) []Change {
+	keys := []string{}
+	for key := range before {
+		keys = append(keys, key)
+	}
+
+	for key := range after {
+		if _, found := before[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	changes := []Change{}
+	for _, key := range keys {
+		keyPath := joinPath(path, key)
+
+		beforeValue, inBefore := before[key]
+		afterValue, inAfter := after[key]
+
+		switch {
+		case !inBefore:
+			changes = append(changes, Change{Path: keyPath, Action: "added", After: afterValue})
+		case !inAfter:
+			changes = append(changes, Change{Path: keyPath, Action: "removed", Before: beforeValue})
+		default:
+			changes = append(changes, diffValues(keyPath, beforeValue, afterValue)...)
+		}
+	}
+
+	return changes
+}
+
+// diffLists pairs up the elements of two lists by their identity, so that
+// inserting or removing a step doesn't show every later step as changed.
+// Elements without an identity are paired in order, and those left over are
+// paired with ones that differ only in name, which are taken to be renames.
+func diffLists(path string, before, after []interface{}) []Change {
+	pairs := make([]int, len(after)) // index in before for each element of after
+	paired := make([]bool, len(before))
+
+	for j := range pairs {
+		pairs[j] = -1
+	}
+
+	for j, element := range after {
+		id, ok := identity(element)
+		if !ok {
+			continue
+		}
+
+		for i, candidate := range before {
+			if paired[i] {
+				continue
+			}
+
+			if candidateID, ok := identity(candidate); ok && candidateID == id {
+				pairs[j] = i
+				paired[i] = true
+				break
+			}
+		}
+	}
+
+	for j, element := range after {
+		if pairs[j] != -1 {
+			continue
+		}
+
+		if _, ok := identity(element); ok {
+			continue
+		}
+
+		for i, candidate := range before {
+			if paired[i] {
+				continue
+			}
+
+			if _, ok := identity(candidate); !ok {
+				pairs[j] = i
+				paired[i] = true
+				break
+			}
+		}
+	}
+
+	for j, element := range after {
+		if pairs[j] != -1 {
+			continue
+		}
+
+		for i, candidate := range before {
+			if !paired[i] && renamed(candidate, element) {
+				pairs[j] = i
+				paired[i] = true
+				break
+			}
+		}
+	}
+
+	changes := []Change{}
+
+	for i, element := range before {
+		if !paired[i] {
+			changes = append(changes, Change{Path: indexPath(path, i), Action: "removed", Before: element})
+		}
+	}
+
+	inOrder := stayedInOrder(pairs)
+
+	for j, element := range after {
+		i := pairs[j]
+		if i == -1 {
+			changes = append(changes, Change{Path: indexPath(path, j), Action: "added", After: element})
+			continue
+		}
+
+		if !inOrder[j] {
+			changes = append(changes, Change{Path: indexPath(path, j), Action: "moved", From: indexPath(path, i)})
+		}
+
+		changes = append(changes, diffValues(indexPath(path, j), before[i], element)...)
+	}
+
+	return changes
+}
+
+// stayedInOrder returns, for each element of the new list, whether it kept
+// its order relative to the others. The largest set of elements that kept
+// their order is taken to have stayed put, and the rest to have moved.
+func stayedInOrder(pairs []int) []bool {
+	inOrder := make([]bool, len(pairs))
+
+	// longest increasing subsequence of the paired indices
+	length := make([]int, len(pairs))
+	previous := make([]int, len(pairs))
+
+	best := -1
+	for j := range pairs {
+		previous[j] = -1
+
+		if pairs[j] == -1 {
+			continue
+		}
+
+		length[j] = 1
+
+		for k := 0; k < j; k++ {
+			if pairs[k] != -1 && pairs[k] < pairs[j] && length[k]+1 > length[j] {
+				length[j] = length[k] + 1
+				previous[j] = k
+			}
+		}
+
+		if best == -1 || length[j] > length[best] {
+			best = j
+		}
+	}
+
+	for j := best; j != -1; j = previous[j] {
+		inOrder[j] = true
+	}
+
+	return inOrder
+}
+
+func identity(element interface{}) (string, bool) {
+	switch v := element.(type) {
+	case map[string]interface{}:
+		for _, key := range identityKeys {
+			if name, ok := v[key].(string); ok {
+				return key + ":" + name, true
+			}
+		}
+
+		return "", false
+
+	case []interface{}, nil:
+		return "", false
+
+	default:
+		return fmt.Sprintf("%T:%v", v, v), true
+	}
+}
+
+// renamed returns whether two list elements are the same but for the field
+// that names them.
+func renamed(before, after interface{}) bool {
+	beforeMap, ok := before.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	afterMap, ok := after.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, key := range identityKeys {
+		_, inBefore := beforeMap[key]
+		_, inAfter := afterMap[key]
+
+		if inBefore != inAfter {
+			return false
+		}
+
+		if inBefore {
+			return reflect.DeepEqual(withoutKey(beforeMap, key), withoutKey(afterMap, key))
+		}
+	}
+
+	return false
+}
+
+func withoutKey(object map[string]interface{}, without string) map[string]interface{} {
+	copied := map[string]interface{}{}
+	for key, value := range object {
+		if key != without {
+			copied[key] = value
+		}
+	}
+
+	return copied
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func indexPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
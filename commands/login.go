@@ -15,6 +15,7 @@ import (
 
 type LoginCommand struct {
 	ATCURL   string `short:"c" long:"concourse-url" description:"Concourse URL to authenticate with"`
+	TeamName string `short:"n" long:"team-name" description:"Team to authenticate with (defaults to the target's team, or main)"`
 	Insecure bool   `short:"k" long:"insecure" description:"Skip verification of the endpoint's SSL certificate"`
 	Username string `short:"u" long:"username" description:"Username for basic auth"`
 	Password string `short:"p" long:"password" description:"Password for basic auth"`
@@ -26,15 +27,24 @@ func (command *LoginCommand) Execute(args []string) error {
 	}
 
 	var client concourse.Client
-	var err error
 
 	if command.ATCURL != "" {
-		client = rc.NewClient(command.ATCURL, command.Insecure)
+		if command.TeamName == "" {
+			command.TeamName = rc.DefaultTeamName
+		}
+
+		client = rc.NewClient(command.ATCURL, command.TeamName, command.Insecure)
 	} else {
-		client, err = rc.CommandTargetClient(Fly.Target, &command.Insecure)
-	}
-	if err != nil {
-		return err
+		target, err := rc.SelectTarget(Fly.Target)
+		if err != nil {
+			return err
+		}
+
+		if command.TeamName == "" {
+			command.TeamName = target.TeamName
+		}
+
+		client = rc.NewClient(target.API, command.TeamName, command.Insecure)
 	}
 
 	authMethods, err := client.ListAuthMethods()
@@ -135,7 +145,7 @@ func (command *LoginCommand) loginWith(method atc.AuthMethod, client concourse.C
 			password = string(interactivePassword)
 		}
 
-		newUnauthedClient := rc.NewClient(client.URL(), command.Insecure)
+		newUnauthedClient := rc.NewClient(client.URL(), command.TeamName, command.Insecure)
 
 		basicAuthClient := concourse.NewClient(
 			newUnauthedClient.URL(),
@@ -155,24 +165,25 @@ func (command *LoginCommand) loginWith(method atc.AuthMethod, client concourse.C
 		}
 	}
 
-	return command.saveTarget(
-		client.URL(),
-		&rc.TargetToken{
-			Type:  token.Type,
-			Value: token.Value,
-		},
-	)
+	targetToken := &rc.TargetToken{
+		Type:  token.Type,
+		Value: token.Value,
+	}
+
+	if expiry, ok := rc.TokenExpiry(token.Value); ok {
+		targetToken.Expiry = expiry.Unix()
+	}
+
+	return command.saveTarget(client.URL(), targetToken)
 }
 
 func (command *LoginCommand) saveTarget(url string, token *rc.TargetToken) error {
 	err := rc.SaveTarget(
 		Fly.Target,
 		url,
+		command.TeamName,
 		command.Insecure,
-		&rc.TargetToken{
-			Type:  token.Type,
-			Value: token.Value,
-		},
+		token,
 	)
 	if err != nil {
 		return err
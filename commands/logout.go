@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+)
+
+type LogoutCommand struct {
+	All bool `short:"a" long:"all" description:"Log out of all targets"`
+}
+
+func (command *LogoutCommand) Execute([]string) error {
+	if command.All {
+		if Fly.Target != "" {
+			return errors.New("--target and --all are mutually exclusive")
+		}
+
+		targets, err := rc.LoadTargets()
+		if err != nil {
+			return err
+		}
+
+		for name := range targets {
+			err := rc.LogoutTarget(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("logged out of all targets")
+
+		return nil
+	}
+
+	if Fly.Target == "" {
+		return rc.ErrNoTargetSpecified
+	}
+
+	err := rc.LogoutTarget(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("logged out of target '%s'\n", Fly.Target)
+
+	return nil
+}
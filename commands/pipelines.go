@@ -3,6 +3,8 @@ package commands
 import (
 	"os"
 
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/ui"
 	"github.com/fatih/color"
@@ -21,6 +23,14 @@ func (command *PipelinesCommand) Execute([]string) error {
 		return err
 	}
 
+	if Fly.JSON {
+		if pipelines == nil {
+			pipelines = []atc.Pipeline{}
+		}
+
+		return displayhelpers.JSONPrint(pipelines)
+	}
+
 	table := ui.Table{
 		Headers: ui.TableRow{
 			{Contents: "name", Color: color.New(color.Bold)},
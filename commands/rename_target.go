@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/concourse/fly/rc"
+)
+
+type RenameTargetCommand struct {
+	NewName rc.TargetName `short:"n" long:"new-name" required:"true" description:"New name for the target"`
+}
+
+func (command *RenameTargetCommand) Execute([]string) error {
+	if Fly.Target == "" {
+		return rc.ErrNoTargetSpecified
+	}
+
+	err := rc.RenameTarget(Fly.Target, command.NewName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("renamed target '%s' to '%s'\n", Fly.Target, command.NewName)
+
+	return nil
+}
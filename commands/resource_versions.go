@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/go-concourse/concourse"
+)
+
+type ResourceVersionsCommand struct {
+	Resource flaghelpers.ResourceFlag `short:"r" long:"resource" required:"true" value-name:"PIPELINE/RESOURCE" description:"Name of the resource to list versions of"`
+	Count    int                      `short:"c" long:"count" default:"50"                                         description:"Number of versions to limit the return to"`
+	Since    int                      `          long:"since"                    value-name:"ID"                 description:"Only list versions newer than the given version ID"`
+	Until    int                      `          long:"until"                    value-name:"ID"                 description:"Only list versions older than the given version ID"`
+}
+
+func (command *ResourceVersionsCommand) Execute([]string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	page := concourse.Page{
+		Limit: command.Count,
+		Since: command.Since,
+		Until: command.Until,
+	}
+
+	versions, _, found, err := client.ResourceVersions(
+		command.Resource.PipelineName,
+		command.Resource.ResourceName,
+		page,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		displayhelpers.Failf("pipeline/resource not found")
+	}
+
+	if Fly.JSON {
+		if versions == nil {
+			versions = []atc.VersionedResource{}
+		}
+
+		return displayhelpers.JSONPrint(versions)
+	}
+
+	return resourceVersionsTable(versions).Render(os.Stdout)
+}
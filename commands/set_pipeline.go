@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/concourse/atc/web"
 	"github.com/concourse/fly/commands/internal/flaghelpers"
 	"github.com/concourse/fly/commands/internal/setpipelinehelpers"
@@ -13,11 +16,22 @@ type SetPipelineCommand struct {
 	Pipeline        string                         `short:"p"  long:"pipeline" required:"true"      description:"Pipeline to configure"`
 	Config          flaghelpers.PathFlag           `short:"c"  long:"config" required:"true"        description:"Pipeline configuration file"`
 	Var             []flaghelpers.VariablePairFlag `short:"v"  long:"var" value-name:"[SECRET=KEY]" description:"Variable flag that can be used for filling in template values in configuration"`
+	YAMLVar         []flaghelpers.VariablePairFlag `short:"y"  long:"yaml-var" value-name:"NAME=YAML" description:"Like --var, but with the value parsed as YAML, e.g. a number, a list or a map"`
 	VarsFrom        []flaghelpers.PathFlag         `short:"l"  long:"load-vars-from"                description:"Variable flag that can be used for filling in template values in configuration from a YAML file"`
 	SkipInteractive bool                           `short:"n"  long:"non-interactive"               description:"Skips interactions, uses default values"`
+	DryRun          bool                           `           long:"dry-run"                       description:"Show the changes without applying them, exiting 2 if there are any"`
+	DiffFormat      string                         `           long:"diff-format" default:"text" choice:"text" choice:"json" choice:"patch" description:"Show the changes as coloured YAML, as JSON, or as a unified diff; json and patch require --dry-run"`
+	SecretVars      []string                       `           long:"secret-var" value-name:"NAME"  description:"A template variable whose value is hidden when showing the changes; may be a pattern like '*_key' (can be specified multiple times)"`
+	VarsProviders   []string                       `           long:"vars-provider" value-name:"SPEC" description:"Resolve template variables from env[:PREFIX], encrypted:PATH or command:COMMAND, instead of the target's providers; their values are hidden when showing the changes (can be specified multiple times)"`
 }
 
 func (command *SetPipelineCommand) Execute(args []string) error {
+	// the prompt and the summary of what was applied would otherwise be mixed
+	// into output meant for other tools
+	if command.DiffFormat != "text" && !command.DryRun {
+		return fmt.Errorf("--diff-format %s can only be used with --dry-run", command.DiffFormat)
+	}
+
 	configPath := command.Config
 	templateVariablesFiles := command.VarsFrom
 	pipelineName := command.Pipeline
@@ -27,11 +41,30 @@ func (command *SetPipelineCommand) Execute(args []string) error {
 		templateVariables[v.Name] = v.Value
 	}
 
+	for _, v := range command.YAMLVar {
+		value, err := template.ParseValue(v.Value)
+		if err != nil {
+			return fmt.Errorf("invalid value for variable '%s': %s", v.Name, err)
+		}
+
+		templateVariables[v.Name] = value
+	}
+
 	client, err := rc.TargetClient(Fly.Target)
 	if err != nil {
 		return err
 	}
 
+	target, err := rc.SelectTarget(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	varsProviders, err := parseVarsProviders(command.VarsProviders, target)
+	if err != nil {
+		return err
+	}
+
 	webRequestGenerator := rata.NewRequestGenerator(client.URL(), web.Routes)
 
 	atcConfig := setpipelinehelpers.ATCConfig{
@@ -39,6 +72,22 @@ func (command *SetPipelineCommand) Execute(args []string) error {
 		WebRequestGenerator: webRequestGenerator,
 		Client:              client,
 		SkipInteraction:     command.SkipInteractive,
+		DiffFormat:          command.DiffFormat,
+		SecretVars:          append(target.SecretVars, command.SecretVars...),
+		VarsProviders:       varsProviders,
+	}
+
+	if command.DryRun {
+		changed, err := atcConfig.DryRun(configPath, templateVariables, templateVariablesFiles)
+		if err != nil {
+			return err
+		}
+
+		if changed {
+			os.Exit(2)
+		}
+
+		return nil
 	}
 
 	return atcConfig.Set(configPath, templateVariables, templateVariablesFiles)
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/concourse/fly/commands/internal/displayhelpers"
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+)
+
+type TargetsCommand struct{}
+
+// targetJSON is what --json prints for each target, which leaves out the
+// token itself.
+type targetJSON struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Team     string `json:"team"`
+	Insecure bool   `json:"insecure"`
+	Token    string `json:"token"`
+}
+
+func (command *TargetsCommand) Execute([]string) error {
+	targets, err := rc.LoadTargets()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range targets {
+		names = append(names, string(name))
+	}
+
+	sort.Strings(names)
+
+	if Fly.JSON {
+		printed := []targetJSON{}
+		for _, name := range names {
+			target := targets[rc.TargetName(name)]
+
+			printed = append(printed, targetJSON{
+				Name:     name,
+				URL:      target.API,
+				Team:     target.TeamName,
+				Insecure: target.Insecure,
+				Token:    tokenStatusCell(target.Token).Contents,
+			})
+		}
+
+		return displayhelpers.JSONPrint(printed)
+	}
+
+	table := ui.Table{
+		Headers: ui.TableRow{
+			{Contents: "name", Color: color.New(color.Bold)},
+			{Contents: "url", Color: color.New(color.Bold)},
+			{Contents: "team", Color: color.New(color.Bold)},
+			{Contents: "insecure", Color: color.New(color.Bold)},
+			{Contents: "token", Color: color.New(color.Bold)},
+		},
+	}
+
+	for _, name := range names {
+		target := targets[rc.TargetName(name)]
+
+		var insecureCell ui.TableCell
+		if target.Insecure {
+			insecureCell.Contents = "yes"
+			insecureCell.Color = color.New(color.FgYellow)
+		} else {
+			insecureCell.Contents = "no"
+		}
+
+		table.Data = append(table.Data, ui.TableRow{
+			{Contents: name},
+			{Contents: target.API},
+			{Contents: target.TeamName},
+			insecureCell,
+			tokenStatusCell(target.Token),
+		})
+	}
+
+	return table.Render(os.Stdout)
+}
+
+func tokenStatusCell(token *rc.TargetToken) ui.TableCell {
+	if token == nil || token.Value == "" {
+		return ui.TableCell{Contents: "none", Color: color.New(color.Faint)}
+	}
+
+	// opaque tokens, and tokens without an expiry, can't be checked here
+	expiresAt, ok := token.ExpiresAt()
+	if !ok {
+		return ui.TableCell{Contents: "unknown", Color: color.New(color.Faint)}
+	}
+
+	remaining := expiresAt.Sub(time.Now())
+	if remaining <= 0 {
+		return ui.TableCell{Contents: "expired", Color: ui.FailedColor}
+	}
+
+	if remaining <= rc.TokenExpiryWarning {
+		return ui.TableCell{Contents: "expiring", Color: ui.StartedColor}
+	}
+
+	return ui.TableCell{Contents: "valid"}
+}
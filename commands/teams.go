@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+)
+
+type TeamsCommand struct{}
+
+func (command *TeamsCommand) Execute([]string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	teams, err := client.ListTeams()
+	if err != nil {
+		return err
+	}
+
+	sort.Sort(teamsByName(teams))
+
+	if Fly.JSON {
+		if teams == nil {
+			teams = []atc.Team{}
+		}
+
+		return displayhelpers.JSONPrint(teams)
+	}
+
+	table := ui.Table{
+		Headers: ui.TableRow{
+			{Contents: "name", Color: color.New(color.Bold)},
+			{Contents: "auth", Color: color.New(color.Bold)},
+		},
+	}
+
+	for _, t := range teams {
+		var authMethods []string
+		if t.BasicAuth.BasicAuthUsername != "" {
+			authMethods = append(authMethods, "basic")
+		}
+
+		if t.GitHubAuth.ClientID != "" {
+			authMethods = append(authMethods, "github")
+		}
+
+		table.Data = append(table.Data, ui.TableRow{
+			{Contents: t.Name},
+			stringOrDefault(strings.Join(authMethods, ", ")),
+		})
+	}
+
+	return table.Render(os.Stdout)
+}
+
+type teamsByName []atc.Team
+
+func (ts teamsByName) Len() int               { return len(ts) }
+func (ts teamsByName) Swap(i int, j int)      { ts[i], ts[j] = ts[j], ts[i] }
+func (ts teamsByName) Less(i int, j int) bool { return ts[i].Name < ts[j].Name }
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/concourse/fly/commands/internal/flaghelpers"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/fly/rc"
+)
+
+type TriggerJobCommand struct {
+	Job   flaghelpers.JobFlag `short:"j" long:"job"   required:"true" value-name:"PIPELINE/JOB" description:"Name of a job to trigger"`
+	Watch bool                `short:"w" long:"watch"                                           description:"Start watching the build output"`
+}
+
+func (command *TriggerJobCommand) Execute(args []string) error {
+	client, err := rc.TargetClient(Fly.Target)
+	if err != nil {
+		return err
+	}
+
+	build, err := client.CreateJobBuild(command.Job.PipelineName, command.Job.JobName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("started %s/%s #%s\n", command.Job.PipelineName, command.Job.JobName, build.Name)
+
+	if !command.Watch {
+		return nil
+	}
+
+	fmt.Println("")
+
+	eventSource, err := client.BuildEvents(strconv.Itoa(build.ID))
+	if err != nil {
+		return err
+	}
+
+	exitCode := renderEvents("text", eventstream.RenderOptions{}, eventSource)
+
+	eventSource.Close()
+
+	os.Exit(exitCode)
+
+	return nil
+}
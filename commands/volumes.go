@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/ui"
 	"github.com/fatih/color"
@@ -26,6 +27,16 @@ func (command *VolumesCommand) Execute([]string) error {
 		return err
 	}
 
+	sort.Sort(volumesByWorkerAndHandle(volumes))
+
+	if Fly.JSON {
+		if volumes == nil {
+			volumes = []atc.Volume{}
+		}
+
+		return displayhelpers.JSONPrint(volumes)
+	}
+
 	table := ui.Table{
 		Headers: ui.TableRow{
 			{Contents: "handle", Color: color.New(color.Bold)},
@@ -36,8 +47,6 @@ func (command *VolumesCommand) Execute([]string) error {
 		},
 	}
 
-	sort.Sort(volumesByWorkerAndHandle(volumes))
-
 	for _, c := range volumes {
 		row := ui.TableRow{
 			{Contents: c.ID},
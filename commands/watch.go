@@ -10,11 +10,24 @@ import (
 )
 
 type WatchCommand struct {
-	Job   flaghelpers.JobFlag `short:"j" long:"job"   value-name:"PIPELINE/JOB"   description:"Watches builds of the given job"`
-	Build string              `short:"b" long:"build"                               description:"Watches a specific build"`
+	Job          flaghelpers.JobFlag `short:"j" long:"job"   value-name:"PIPELINE/JOB"   description:"Watches builds of the given job"`
+	Build        string              `short:"b" long:"build"                               description:"Watches a specific build"`
+	OutputFormat string              `long:"output-format" default:"text" choice:"text" choice:"jsonl" description:"Render events as text, or as one JSON object per line"`
+	Timestamps   string              `long:"timestamps" choice:"elapsed" choice:"wall"                  description:"Prefix each log line with the time since the build started, or the wall-clock time"`
+	StepNames    bool                `long:"step-names"                                                 description:"Prefix each log line with the name of the step that printed it"`
 }
 
 func (command *WatchCommand) Execute(args []string) error {
+	renderOptions := eventstream.RenderOptions{
+		Timestamps:    command.Timestamps,
+		ShowStepNames: command.StepNames,
+	}
+
+	err := validateRenderOptions(command.OutputFormat, renderOptions)
+	if err != nil {
+		return err
+	}
+
 	client, err := rc.TargetClient(Fly.Target)
 	if err != nil {
 		return err
@@ -30,7 +43,7 @@ func (command *WatchCommand) Execute(args []string) error {
 		return err
 	}
 
-	exitCode := eventstream.Render(os.Stdout, eventSource)
+	exitCode := renderEvents(command.OutputFormat, renderOptions, eventSource)
 
 	eventSource.Close()
 
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/commands/internal/displayhelpers"
 	"github.com/concourse/fly/rc"
 	"github.com/concourse/fly/ui"
 	"github.com/fatih/color"
@@ -27,6 +28,16 @@ func (command *WorkersCommand) Execute([]string) error {
 		return err
 	}
 
+	sort.Sort(byWorkerName(workers))
+
+	if Fly.JSON {
+		if workers == nil {
+			workers = []atc.Worker{}
+		}
+
+		return displayhelpers.JSONPrint(workers)
+	}
+
 	headers := ui.TableRow{
 		{Contents: "name", Color: color.New(color.Bold)},
 		{Contents: "containers", Color: color.New(color.Bold)},
@@ -44,8 +55,6 @@ func (command *WorkersCommand) Execute([]string) error {
 
 	table := ui.Table{Headers: headers}
 
-	sort.Sort(byWorkerName(workers))
-
 	for _, w := range workers {
 		row := ui.TableRow{
 			{Contents: w.Name},
@@ -6,6 +6,7 @@ import (
 	"syscall"
 
 	"github.com/concourse/atc"
+	"github.com/concourse/fly/template"
 )
 
 func LoadTaskConfig(configPath string, args []string) (atc.TaskConfig, error) {
@@ -19,6 +20,39 @@ func LoadTaskConfig(configPath string, args []string) (atc.TaskConfig, error) {
 		return atc.TaskConfig{}, err
 	}
 
+	return OverrideTaskConfig(config, args), nil
+}
+
+// LoadTemplatedTaskConfig is like LoadTaskConfig, but first evaluates the
+// template variables in the task config. The variables given take precedence
+// over those resolved from the providers, which are only asked for the rest.
+func LoadTemplatedTaskConfig(configPath string, args []string, providers []template.Provider, variables template.Variables) (atc.TaskConfig, error) {
+	configFile, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return atc.TaskConfig{}, fmt.Errorf("failed to read task config: %s", err)
+	}
+
+	resolved, err := template.ResolveVariables(configFile, providers, variables)
+	if err != nil {
+		return atc.TaskConfig{}, fmt.Errorf("failed to resolve variables from providers: %s", err)
+	}
+
+	configFile, err = template.Evaluate(configFile, resolved.Merge(variables))
+	if err != nil {
+		return atc.TaskConfig{}, fmt.Errorf("failed to evaluate variables into task config: %s", err)
+	}
+
+	config, err := atc.LoadTaskConfig(configFile)
+	if err != nil {
+		return atc.TaskConfig{}, err
+	}
+
+	return OverrideTaskConfig(config, args), nil
+}
+
+// OverrideTaskConfig appends the arguments to the task's run args, and
+// replaces the value of any param that is set in the environment.
+func OverrideTaskConfig(config atc.TaskConfig, args []string) atc.TaskConfig {
 	config.Run.Args = append(config.Run.Args, args...)
 
 	for k, _ := range config.Params {
@@ -28,5 +62,5 @@ func LoadTaskConfig(configPath string, args []string) (atc.TaskConfig, error) {
 		}
 	}
 
-	return config, nil
+	return config
 }
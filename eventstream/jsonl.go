@@ -0,0 +1,104 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/go-concourse/concourse/eventstream"
+)
+
+type jsonlEvent struct {
+	Type    atc.EventType    `json:"type"`
+	Version atc.EventVersion `json:"version"`
+	Time    int64            `json:"time,omitempty"`
+	Origin  *event.Origin    `json:"origin,omitempty"`
+	Payload atc.Event        `json:"payload"`
+}
+
+// jsonlError is written in place of an event that couldn't be read, so that
+// every line of the stream is still JSON.
+type jsonlError struct {
+	Error string `json:"error"`
+}
+
+// RenderJSONL writes each event in the stream to dst as one JSON object per
+// line. The returned exit status is the same as Render's.
+func RenderJSONL(dst io.Writer, src eventstream.EventStream) int {
+	encoder := json.NewEncoder(dst)
+
+	exitStatus := 0
+
+	for {
+		ev, err := src.NextEvent()
+		if err != nil {
+			if err == io.EOF {
+				return exitStatus
+			} else {
+				encoder.Encode(jsonlError{Error: fmt.Sprintf("failed to parse next event: %s", err)})
+				return 255
+			}
+		}
+
+		err = encoder.Encode(newJSONLEvent(ev))
+		if err != nil {
+			return 255
+		}
+
+		switch e := ev.(type) {
+		case event.FinishTask:
+			exitStatus = e.ExitStatus
+
+		case event.Status:
+			if e.Status == "started" {
+				continue
+			}
+
+			exitStatus, _ = statusExitCode(string(e.Status), exitStatus)
+
+			return exitStatus
+		}
+	}
+}
+
+func newJSONLEvent(ev atc.Event) jsonlEvent {
+	line := jsonlEvent{
+		Type:    ev.EventType(),
+		Version: ev.Version(),
+		Payload: ev,
+	}
+
+	switch e := ev.(type) {
+	case event.Log:
+		line.Time = e.Time
+		line.Origin = originOf(e.Origin)
+	case event.InitializeTask:
+		line.Time = e.Time
+		line.Origin = originOf(e.Origin)
+	case event.StartTask:
+		line.Time = e.Time
+		line.Origin = originOf(e.Origin)
+	case event.FinishTask:
+		line.Time = e.Time
+		line.Origin = originOf(e.Origin)
+	case event.Error:
+		line.Origin = originOf(e.Origin)
+	case event.Status:
+		line.Time = e.Time
+	}
+
+	return line
+}
+
+// originOf returns nil for an event without an origin, so that it's left out
+// of the line.
+func originOf(origin event.Origin) *event.Origin {
+	if reflect.DeepEqual(origin, event.Origin{}) {
+		return nil
+	}
+
+	return &origin
+}
@@ -0,0 +1,184 @@
+package eventstream_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/go-concourse/concourse/eventstream/fakes"
+)
+
+var _ = Describe("JSONL Renderer", func() {
+	var (
+		out    *gbytes.Buffer
+		stream *fakes.FakeEventStream
+
+		receivedEvents chan<- atc.Event
+
+		exitStatus int
+	)
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+		stream = new(fakes.FakeEventStream)
+
+		events := make(chan atc.Event, 100)
+		receivedEvents = events
+
+		stream.NextEventStub = func() (atc.Event, error) {
+			select {
+			case ev := <-events:
+				return ev, nil
+			default:
+				return nil, io.EOF
+			}
+		}
+	})
+
+	JustBeforeEach(func() {
+		exitStatus = eventstream.RenderJSONL(out, stream)
+	})
+
+	lines := func() []map[string]interface{} {
+		var decoded []map[string]interface{}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(out.Contents())), "\n") {
+			var obj map[string]interface{}
+			err := json.Unmarshal([]byte(line), &obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			decoded = append(decoded, obj)
+		}
+
+		return decoded
+	}
+
+	Context("when a Log event is received", func() {
+		BeforeEach(func() {
+			receivedEvents <- event.Log{
+				Time:    1234,
+				Origin:  event.Origin{Name: "some-step"},
+				Payload: "hello",
+			}
+		})
+
+		It("prints it as a single line of JSON with its type, time, origin and payload", func() {
+			decoded := lines()
+			Expect(decoded).To(HaveLen(1))
+
+			Expect(decoded[0]["type"]).To(Equal("log"))
+			Expect(decoded[0]["time"]).To(BeNumerically("==", 1234))
+			Expect(decoded[0]["origin"]).To(HaveKeyWithValue("name", "some-step"))
+			Expect(decoded[0]["payload"]).To(HaveKeyWithValue("payload", "hello"))
+		})
+	})
+
+	Context("when a FinishTask event is received", func() {
+		BeforeEach(func() {
+			receivedEvents <- event.FinishTask{
+				ExitStatus: 42,
+			}
+		})
+
+		It("returns its exit status", func() {
+			Expect(exitStatus).To(Equal(42))
+		})
+
+		It("leaves out the origin it doesn't have", func() {
+			Expect(lines()[0]).NotTo(HaveKey("origin"))
+		})
+
+		Context("and a Status event is received", func() {
+			BeforeEach(func() {
+				receivedEvents <- event.Status{
+					Status: atc.StatusSucceeded,
+				}
+			})
+
+			It("prints both events", func() {
+				Expect(lines()).To(HaveLen(2))
+			})
+
+			It("exits with the status from the FinishTask event", func() {
+				Expect(exitStatus).To(Equal(42))
+			})
+		})
+	})
+
+	Context("when the next event cannot be read", func() {
+		BeforeEach(func() {
+			stream.NextEventStub = func() (atc.Event, error) {
+				return nil, errors.New("bad event")
+			}
+		})
+
+		It("prints the error as a line of JSON", func() {
+			decoded := lines()
+			Expect(decoded).To(HaveLen(1))
+			Expect(decoded[0]).To(HaveKeyWithValue("error", "failed to parse next event: bad event"))
+		})
+
+		It("exits 255", func() {
+			Expect(exitStatus).To(Equal(255))
+		})
+	})
+
+	Describe("receiving a Status event", func() {
+		Context("with status 'succeeded'", func() {
+			BeforeEach(func() {
+				receivedEvents <- event.Status{
+					Status: atc.StatusSucceeded,
+				}
+			})
+
+			It("exits 0", func() {
+				Expect(exitStatus).To(Equal(0))
+			})
+		})
+
+		Context("with status 'failed'", func() {
+			BeforeEach(func() {
+				receivedEvents <- event.Status{
+					Status: atc.StatusFailed,
+				}
+			})
+
+			It("exits 1", func() {
+				Expect(exitStatus).To(Equal(1))
+			})
+		})
+
+		Context("with status 'errored'", func() {
+			BeforeEach(func() {
+				receivedEvents <- event.Status{
+					Status: atc.StatusErrored,
+				}
+			})
+
+			It("exits 2", func() {
+				Expect(exitStatus).To(Equal(2))
+			})
+		})
+
+		Context("with status 'aborted'", func() {
+			BeforeEach(func() {
+				receivedEvents <- event.Status{
+					Status: atc.StatusAborted,
+				}
+			})
+
+			It("exits 3", func() {
+				Expect(exitStatus).To(Equal(3))
+			})
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package eventstream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/concourse/atc/event"
+)
+
+const (
+	TimestampsNone    = ""
+	TimestampsElapsed = "elapsed"
+	TimestampsWall    = "wall"
+)
+
+type RenderOptions struct {
+	Timestamps    string
+	ShowStepNames bool
+}
+
+func (options RenderOptions) prefixesLogs() bool {
+	return options.Timestamps != TimestampsNone || options.ShowStepNames
+}
+
+// logPrefixer buffers log payloads per origin so that a prefix is only
+// written at the start of each complete line, even when a line is split
+// across several events.
+type logPrefixer struct {
+	dst     io.Writer
+	options RenderOptions
+
+	start int64
+
+	partials map[string]*partialLine
+	order    []string
+}
+
+type partialLine struct {
+	time   int64
+	origin event.Origin
+	buf    bytes.Buffer
+}
+
+func newLogPrefixer(dst io.Writer, options RenderOptions) *logPrefixer {
+	return &logPrefixer{
+		dst:      dst,
+		options:  options,
+		partials: map[string]*partialLine{},
+	}
+}
+
+func (prefixer *logPrefixer) startAt(t int64) {
+	if prefixer.start == 0 {
+		prefixer.start = t
+	}
+}
+
+func (prefixer *logPrefixer) write(log event.Log) {
+	prefixer.startAt(log.Time)
+
+	key := log.Origin.Name + "/" + string(log.Origin.Source)
+
+	partial, found := prefixer.partials[key]
+	if !found {
+		partial = &partialLine{origin: log.Origin}
+		prefixer.partials[key] = partial
+		prefixer.order = append(prefixer.order, key)
+	}
+
+	payload := []byte(log.Payload)
+
+	for len(payload) > 0 {
+		if partial.buf.Len() == 0 {
+			partial.time = log.Time
+		}
+
+		i := bytes.IndexByte(payload, '\n')
+		if i == -1 {
+			partial.buf.Write(payload)
+			break
+		}
+
+		partial.buf.Write(payload[:i+1])
+		prefixer.emit(partial)
+
+		payload = payload[i+1:]
+	}
+}
+
+func (prefixer *logPrefixer) flush() {
+	for _, key := range prefixer.order {
+		partial := prefixer.partials[key]
+		if partial.buf.Len() > 0 {
+			partial.buf.WriteByte('\n')
+			prefixer.emit(partial)
+		}
+	}
+}
+
+func (prefixer *logPrefixer) emit(partial *partialLine) {
+	fmt.Fprintf(prefixer.dst, "%s%s", prefixer.prefix(partial), partial.buf.Bytes())
+	partial.buf.Reset()
+}
+
+func (prefixer *logPrefixer) prefix(partial *partialLine) string {
+	var prefix string
+
+	switch prefixer.options.Timestamps {
+	case TimestampsElapsed:
+		elapsed := time.Duration(partial.time-prefixer.start) * time.Second
+		prefix += fmt.Sprintf(
+			"[%0.2d:%0.2d:%0.2d] ",
+			int64(elapsed.Hours()),
+			int64(elapsed.Minutes())%60,
+			int64(elapsed.Seconds())%60,
+		)
+	case TimestampsWall:
+		prefix += fmt.Sprintf("[%s] ", time.Unix(partial.time, 0).Format("15:04:05"))
+	}
+
+	if prefixer.options.ShowStepNames && partial.origin.Name != "" {
+		prefix += fmt.Sprintf("[%s] ", partial.origin.Name)
+	}
+
+	return prefix
+}
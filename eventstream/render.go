@@ -12,8 +12,15 @@ import (
 )
 
 func Render(dst io.Writer, src eventstream.EventStream) int {
+	return RenderWithOptions(dst, src, RenderOptions{})
+}
+
+func RenderWithOptions(dst io.Writer, src eventstream.EventStream, options RenderOptions) int {
 	var buildConfig event.TaskConfig
 
+	prefixer := newLogPrefixer(dst, options)
+	defer prefixer.flush()
+
 	exitStatus := 0
 
 	for {
@@ -27,9 +34,19 @@ func Render(dst io.Writer, src eventstream.EventStream) int {
 			}
 		}
 
+		// lines still being buffered came before this event, so they're written
+		// out first
+		if _, isLog := ev.(event.Log); !isLog {
+			prefixer.flush()
+		}
+
 		switch e := ev.(type) {
 		case event.Log:
-			fmt.Fprintf(dst, "%s", e.Payload)
+			if options.prefixesLogs() {
+				prefixer.write(e)
+			} else {
+				fmt.Fprintf(dst, "%s", e.Payload)
+			}
 
 		case event.InitializeTask:
 			buildConfig = e.TaskConfig
@@ -56,32 +73,23 @@ func Render(dst io.Writer, src eventstream.EventStream) int {
 
 			switch e.Status {
 			case "started":
+				prefixer.startAt(e.Time)
 				continue
 			case "succeeded":
 				printColor = ui.SucceededColor
 			case "failed":
 				printColor = ui.FailedColor
-
-				if exitStatus == 0 {
-					exitStatus = 1
-				}
 			case "errored":
 				printColor = ui.ErroredColor
-
-				if exitStatus == 0 {
-					exitStatus = 2
-				}
 			case "aborted":
 				printColor = ui.AbortedColor
-
-				if exitStatus == 0 {
-					exitStatus = 3
-				}
 			default:
 				fmt.Fprintf(dst, "unknown status: %s", e.Status)
 				return 255
 			}
 
+			exitStatus, _ = statusExitCode(string(e.Status), exitStatus)
+
 			printColorFunc := printColor.SprintFunc()
 			fmt.Fprintf(dst, "%s\n", printColorFunc(e.Status))
 
@@ -91,3 +99,28 @@ func Render(dst io.Writer, src eventstream.EventStream) int {
 
 	return 255
 }
+
+// statusExitCode maps a build's final status to fly's exit code, preferring
+// an exit status already reported by the task.
+func statusExitCode(status string, exitStatus int) (int, bool) {
+	var statusCode int
+
+	switch status {
+	case "succeeded":
+		statusCode = 0
+	case "failed":
+		statusCode = 1
+	case "errored":
+		statusCode = 2
+	case "aborted":
+		statusCode = 3
+	default:
+		return 255, false
+	}
+
+	if exitStatus != 0 {
+		return exitStatus, true
+	}
+
+	return statusCode, true
+}
@@ -0,0 +1,133 @@
+package eventstream_test
+
+import (
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+	"github.com/concourse/fly/eventstream"
+	"github.com/concourse/go-concourse/concourse/eventstream/fakes"
+)
+
+var _ = Describe("Rendering with options", func() {
+	var (
+		out    *gbytes.Buffer
+		stream *fakes.FakeEventStream
+
+		receivedEvents chan<- atc.Event
+
+		options eventstream.RenderOptions
+	)
+
+	BeforeEach(func() {
+		out = gbytes.NewBuffer()
+		stream = new(fakes.FakeEventStream)
+
+		events := make(chan atc.Event, 100)
+		receivedEvents = events
+
+		stream.NextEventStub = func() (atc.Event, error) {
+			select {
+			case ev := <-events:
+				return ev, nil
+			default:
+				return nil, io.EOF
+			}
+		}
+
+		options = eventstream.RenderOptions{}
+	})
+
+	JustBeforeEach(func() {
+		eventstream.RenderWithOptions(out, stream, options)
+	})
+
+	Context("when step names are shown", func() {
+		BeforeEach(func() {
+			options.ShowStepNames = true
+
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "unit"}, Payload: "hello "}
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "lint"}, Payload: "all clean\n"}
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "unit"}, Payload: "world\nbye\n"}
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "unit"}, Payload: "no newline"}
+		})
+
+		It("prefixes each complete line with the name of the step", func() {
+			Expect(string(out.Contents())).To(Equal(
+				"[lint] all clean\n" +
+					"[unit] hello world\n" +
+					"[unit] bye\n" +
+					"[unit] no newline\n",
+			))
+		})
+	})
+
+	Context("when elapsed timestamps are shown", func() {
+		BeforeEach(func() {
+			options.Timestamps = eventstream.TimestampsElapsed
+
+			receivedEvents <- event.Status{Status: atc.StatusStarted, Time: 1000}
+			receivedEvents <- event.Log{Time: 1005, Payload: "first\n"}
+			receivedEvents <- event.Log{Time: 4725, Payload: "second\n"}
+		})
+
+		It("prefixes each line with the time since the build started", func() {
+			Expect(string(out.Contents())).To(Equal(
+				"[00:00:05] first\n" +
+					"[01:02:05] second\n",
+			))
+		})
+	})
+
+	Context("when wall-clock timestamps are shown along with step names", func() {
+		BeforeEach(func() {
+			options.Timestamps = eventstream.TimestampsWall
+			options.ShowStepNames = true
+
+			receivedEvents <- event.Log{Time: 1000, Origin: event.Origin{Name: "unit"}, Payload: "hello\n"}
+		})
+
+		It("prefixes each line with the time and the step name", func() {
+			expectedTime := time.Unix(1000, 0).Format("15:04:05")
+			Expect(string(out.Contents())).To(Equal("[" + expectedTime + "] [unit] hello\n"))
+		})
+	})
+
+	Context("when a partial line is followed by the build finishing", func() {
+		BeforeEach(func() {
+			options.ShowStepNames = true
+
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "unit"}, Payload: "unterminated"}
+			receivedEvents <- event.Status{Status: atc.StatusSucceeded}
+		})
+
+		It("flushes the partial line before the status", func() {
+			contents := string(out.Contents())
+			Expect(contents).To(HavePrefix("[unit] unterminated\n"))
+			Expect(contents).To(ContainSubstring("succeeded"))
+		})
+	})
+
+	Context("when a partial line is followed by another event", func() {
+		BeforeEach(func() {
+			options.ShowStepNames = true
+
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "unit"}, Payload: "unterminated"}
+			receivedEvents <- event.Error{Message: "oh no"}
+			receivedEvents <- event.Log{Origin: event.Origin{Name: "unit"}, Payload: "more\n"}
+		})
+
+		It("flushes the partial line before rendering the event", func() {
+			contents := string(out.Contents())
+			Expect(contents).To(HavePrefix("[unit] unterminated\n"))
+			Expect(contents).To(ContainSubstring("oh no"))
+			Expect(contents).To(HaveSuffix("[unit] more\n"))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"os/exec"
 	"time"
@@ -180,6 +181,22 @@ var _ = Describe("Fly CLI", func() {
 					Eventually(session).Should(gexec.Exit(1))
 				})
 			})
+
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					cmdArgs = []string{"-t", targetName, "--json", "builds"}
+				})
+
+				It("prints the builds as a JSON array", func() {
+					Eventually(session).Should(gexec.Exit(0))
+
+					var builds []atc.Build
+					err := json.Unmarshal(session.Out.Contents(), &builds)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(builds).To(Equal(returnedBuilds))
+				})
+			})
 		})
 
 		Context("when passing the limit argument", func() {
@@ -0,0 +1,126 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("check-resource", func() {
+		var (
+			flyCmd *exec.Cmd
+		)
+
+		pipelineName := "mypipeline"
+		resourceName := "myresource"
+		fullResourceName := fmt.Sprintf("%s/%s", pipelineName, resourceName)
+		checkPath := fmt.Sprintf("/api/v1/pipelines/%s/resources/%s/check", pipelineName, resourceName)
+		versionsPath := fmt.Sprintf("/api/v1/pipelines/%s/resources/%s/versions", pipelineName, resourceName)
+
+		BeforeEach(func() {
+			flyCmd = exec.Command(flyPath, "-t", targetName, "check-resource", "-r", fullResourceName)
+		})
+
+		Context("when the resource is checked", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "limit=1"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.VersionedResource{
+							{ID: 1, Version: atc.Version{"ref": "aaa"}, Enabled: true},
+						}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", checkPath),
+						ghttp.RespondWith(http.StatusOK, nil),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "since=1&limit=100"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.VersionedResource{
+							{ID: 3, Version: atc.Version{"ref": "ccc"}, Enabled: true},
+							{ID: 2, Version: atc.Version{"ref": "bbb"}, Enabled: false},
+						}),
+					),
+				)
+			})
+
+			It("reports only the versions newer than the latest one before the check", func() {
+				Expect(flyCmd).To(PrintTable(ui.Table{
+					Headers: ui.TableRow{
+						{Contents: "id", Color: color.New(color.Bold)},
+						{Contents: "version", Color: color.New(color.Bold)},
+						{Contents: "enabled", Color: color.New(color.Bold)},
+					},
+					Data: []ui.TableRow{
+						{{Contents: "3"}, {Contents: "ref: ccc"}, {Contents: "yes"}},
+						{{Contents: "2"}, {Contents: "ref: bbb"}, {Contents: "no", Color: color.New(color.FgRed)}},
+					},
+				}))
+			})
+		})
+
+		Context("when a version to check from is given", func() {
+			BeforeEach(func() {
+				flyCmd.Args = append(flyCmd.Args, "--from", "ref:fake-ref")
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "limit=1"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.VersionedResource{}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", checkPath),
+						ghttp.VerifyJSON(`{"from":{"ref":"fake-ref"}}`),
+						ghttp.RespondWith(http.StatusOK, nil),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "limit=100"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.VersionedResource{}),
+					),
+				)
+			})
+
+			It("sends the version to the ATC", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gbytes.Say(fmt.Sprintf("checked '%s'", resourceName)))
+				Eventually(sess).Should(gbytes.Say("no new versions found"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+
+		Context("when the resource does not exist", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "limit=1"),
+						ghttp.RespondWith(http.StatusNotFound, nil),
+					),
+				)
+			})
+
+			It("exits 1 and outputs an error", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("not found"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -92,6 +93,29 @@ var _ = Describe("Fly CLI", func() {
 
 				Expect(flyCmd).To(HaveExited(0))
 			})
+
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--json")
+				})
+
+				It("prints the containers as a JSON array, ordered by handle", func() {
+					sess, err := gexec.Start(flyCmd, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(sess).Should(gexec.Exit(0))
+
+					var containers []atc.Container
+					err = json.Unmarshal(sess.Out.Contents(), &containers)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(containers).To(HaveLen(4))
+					Expect(containers[0].ID).To(Equal("early-handle"))
+					Expect(containers[0].Attempts).To(Equal([]int{1, 5}))
+					Expect(containers[1].ID).To(Equal("handle-1"))
+					Expect(containers[2].ID).To(Equal("other-handle"))
+					Expect(containers[3].ID).To(Equal("post-handle"))
+				})
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
@@ -0,0 +1,151 @@
+package integration_test
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("destroy-team", func() {
+		var (
+			stdin io.Writer
+			args  []string
+			sess  *gexec.Session
+		)
+
+		BeforeEach(func() {
+			stdin = nil
+			args = []string{}
+		})
+
+		JustBeforeEach(func() {
+			var err error
+
+			flyCmd := exec.Command(flyPath, append([]string{"-t", targetName, "destroy-team"}, args...)...)
+			stdin, err = flyCmd.StdinPipe()
+			Expect(err).NotTo(HaveOccurred())
+
+			sess, err = gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when a team name is not specified", func() {
+			It("asks the user to specify a team name", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "destroy-team")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(1))
+
+				Expect(sess.Err).To(gbytes.Say("error: the required flag `[-/]+team-name' was not specified"))
+			})
+		})
+
+		Context("when a team name is specified", func() {
+			BeforeEach(func() {
+				args = append(args, "--team-name", "some-team")
+			})
+
+			yes := func() {
+				Eventually(sess).Should(gbytes.Say(`are you sure\? \[yN\]: `))
+				fmt.Fprintf(stdin, "y\n")
+			}
+
+			no := func() {
+				Eventually(sess).Should(gbytes.Say(`are you sure\? \[yN\]: `))
+				fmt.Fprintf(stdin, "n\n")
+			}
+
+			It("warns that it's about to do bad things", func() {
+				Eventually(sess).Should(gbytes.Say("!!! this will remove all data for team `some-team`"))
+			})
+
+			It("bails out if the user says no", func() {
+				no()
+				Eventually(sess).Should(gbytes.Say(`bailing out`))
+				Eventually(sess).Should(gexec.Exit(0))
+			})
+
+			Context("when the team exists", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("DELETE", "/api/v1/teams/some-team"),
+							ghttp.RespondWith(204, ""),
+						),
+					)
+				})
+
+				It("succeeds if the user says yes", func() {
+					yes()
+					Eventually(sess).Should(gbytes.Say("`some-team` deleted"))
+					Eventually(sess).Should(gexec.Exit(0))
+				})
+
+				Context("when run noninteractively", func() {
+					BeforeEach(func() {
+						args = append(args, "--non-interactive")
+					})
+
+					It("destroys the team without confirming", func() {
+						Eventually(sess).Should(gbytes.Say("`some-team` deleted"))
+						Eventually(sess).Should(gexec.Exit(0))
+					})
+				})
+
+				Context("when run noninteractively with -n", func() {
+					BeforeEach(func() {
+						args = append(args, "-n")
+					})
+
+					It("destroys the team without confirming, like destroy-pipeline", func() {
+						Eventually(sess).Should(gbytes.Say("`some-team` deleted"))
+						Eventually(sess).Should(gexec.Exit(0))
+					})
+				})
+			})
+
+			Context("and the team does not exist", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("DELETE", "/api/v1/teams/some-team"),
+							ghttp.RespondWith(404, ""),
+						),
+					)
+				})
+
+				It("writes that it did not exist and exits successfully", func() {
+					yes()
+					Eventually(sess).Should(gbytes.Say("`some-team` does not exist"))
+					Eventually(sess).Should(gexec.Exit(0))
+				})
+			})
+
+			Context("and the api returns an unexpected status code", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("DELETE", "/api/v1/teams/some-team"),
+							ghttp.RespondWith(402, ""),
+						),
+					)
+				})
+
+				It("writes an error message to stderr", func() {
+					yes()
+					Eventually(sess.Err).Should(gbytes.Say("Unexpected Response"))
+					Eventually(sess).Should(gexec.Exit(1))
+				})
+			})
+		})
+	})
+})
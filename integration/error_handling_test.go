@@ -1,9 +1,14 @@
 package integration_test
 
 import (
+	"io"
 	"net/http"
 	"os/exec"
 
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/pty"
+	"github.com/concourse/fly/rc"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -42,6 +47,88 @@ var _ = Describe("Fly CLI", func() {
 				Expect(sess.Err).To(gbytes.Say(`fly -t ` + targetName + ` login`))
 			})
 		})
+
+		Context("when a 401 response is received in a terminal", func() {
+			var tty pty.PTY
+
+			BeforeEach(func() {
+				err := rc.SaveTarget(
+					targetName,
+					atcServer.URL(),
+					"main",
+					true,
+					&rc.TargetToken{Type: "Bearer", Value: "some-stale-token"},
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				tty, err = pty.Open()
+				Expect(err).NotTo(HaveOccurred())
+
+				flyCmd.Stdin = tty.TTYR
+				flyCmd.Stdout = tty.TTYW
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/containers"),
+						ghttp.RespondWith(401, ""),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/auth/methods"),
+						ghttp.RespondWithJSONEncoded(200, []atc.AuthMethod{}),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/containers"),
+						ghttp.RespondWithJSONEncoded(200, []atc.Container{
+							{ID: "some-handle", WorkerName: "some-worker"},
+						}),
+					),
+				)
+			})
+
+			AfterEach(func() {
+				tty.Close()
+			})
+
+			It("logs in again and retries the command", func() {
+				sess, err := gexec.Start(flyCmd, nil, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				output := gbytes.NewBuffer()
+				go io.Copy(output, tty.PTYR)
+
+				Eventually(sess.Err).Should(gbytes.Say("not authorized for target " + targetName))
+				Eventually(output).Should(gbytes.Say("log in again and retry?"))
+
+				_, err = tty.PTYW.Write([]byte("y\r"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Eventually(output).Should(gbytes.Say("target saved"))
+				Eventually(output).Should(gbytes.Say("some-handle"))
+				Consistently(output).ShouldNot(gbytes.Say("some-handle"))
+			})
+
+			It("keeps the target's settings", func() {
+				sess, err := gexec.Start(flyCmd, nil, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				output := gbytes.NewBuffer()
+				go io.Copy(output, tty.PTYR)
+
+				Eventually(output).Should(gbytes.Say("log in again and retry?"))
+
+				_, err = tty.PTYW.Write([]byte("y\r"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gexec.Exit(0))
+
+				target, err := rc.SelectTarget(targetName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(target.Insecure).To(BeTrue())
+				Expect(target.TeamName).To(Equal("main"))
+			})
+		})
 	})
 
 	Describe("missing target", func() {
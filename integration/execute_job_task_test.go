@@ -0,0 +1,293 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/vito/go-sse/sse"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("execute --job-task", func() {
+		var repoDir string
+
+		var streaming chan struct{}
+		var events chan atc.Event
+
+		var pipelineConfig atc.Config
+		var expectedPlan atc.Plan
+
+		taskConfig := atc.TaskConfig{
+			Platform: "some-platform",
+			Image:    "ubuntu",
+			Inputs: []atc.TaskInputConfig{
+				{Name: "some-repo"},
+			},
+			Params: map[string]string{
+				"FOO": "bar",
+			},
+			Run: atc.TaskRunConfig{
+				Path: "some-repo/ci/unit",
+			},
+		}
+
+		BeforeEach(func() {
+			var err error
+			repoDir, err = ioutil.TempDir("", "fly-job-task")
+			Expect(err).NotTo(HaveOccurred())
+
+			streaming = make(chan struct{})
+			events = make(chan atc.Event)
+
+			inlineConfig := taskConfig
+
+			pipelineConfig = atc.Config{
+				Jobs: atc.JobConfigs{
+					{
+						Name: "some-job",
+						Plan: atc.PlanSequence{
+							{Get: "some-repo"},
+							{
+								Do: &atc.PlanSequence{
+									{
+										Task:       "unit",
+										Privileged: true,
+										Params:     atc.Params{"FOO": "from-step"},
+										TaskConfig: &inlineConfig,
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			planFactory := atc.NewPlanFactory(0)
+
+			expectedConfig := taskConfig
+			expectedConfig.Params = map[string]string{"FOO": "from-step"}
+
+			expectedPlan = planFactory.NewPlan(atc.DoPlan{
+				planFactory.NewPlan(atc.AggregatePlan{
+					planFactory.NewPlan(atc.GetPlan{
+						Name:    "some-repo",
+						Type:    "git",
+						Source:  atc.Source{"uri": "https://example.com"},
+						Version: atc.Version{"ref": "some-ref"},
+					}),
+				}),
+				planFactory.NewPlan(atc.TaskPlan{
+					Name:       "one-off",
+					Privileged: true,
+					Config:     &expectedConfig,
+				}),
+			})
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(repoDir)
+		})
+
+		JustBeforeEach(func() {
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/config",
+				ghttp.RespondWithJSONEncoded(200, pipelineConfig, http.Header{atc.ConfigVersionHeader: {"42"}}),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/inputs",
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.BuildInput{
+					{
+						Name:     "some-repo",
+						Type:     "git",
+						Resource: "some-repo",
+						Source:   atc.Source{"uri": "https://example.com"},
+						Version:  atc.Version{"ref": "some-ref"},
+					},
+				}),
+			)
+			atcServer.RouteToHandler("POST", "/api/v1/builds",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/builds"),
+					VerifyPlan(expectedPlan),
+					ghttp.RespondWith(201, `{"id":128}`),
+				),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/builds/128/events",
+				func(w http.ResponseWriter, r *http.Request) {
+					flusher := w.(http.Flusher)
+
+					w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+					w.WriteHeader(http.StatusOK)
+
+					flusher.Flush()
+
+					close(streaming)
+
+					id := 0
+
+					for e := range events {
+						payload, err := json.Marshal(event.Message{Event: e})
+						Expect(err).NotTo(HaveOccurred())
+
+						err = sse.Event{
+							ID:   fmt.Sprintf("%d", id),
+							Name: "event",
+							Data: payload,
+						}.Write(w)
+						Expect(err).NotTo(HaveOccurred())
+
+						flusher.Flush()
+
+						id++
+					}
+
+					err := sse.Event{
+						Name: "end",
+					}.Write(w)
+					Expect(err).NotTo(HaveOccurred())
+				},
+			)
+		})
+
+		It("runs the step's config with the job's inputs", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--job-task", "some-pipeline/some-job/unit")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming, 5).Should(BeClosed())
+
+			events <- event.Status{Status: atc.StatusSucceeded}
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		Context("when the step maps the task's inputs to the job's", func() {
+			BeforeEach(func() {
+				mappedConfig := taskConfig
+				mappedConfig.Inputs = []atc.TaskInputConfig{{Name: "repo"}}
+
+				step := &(*pipelineConfig.Jobs[0].Plan[1].Do)[0]
+				step.TaskConfig = &mappedConfig
+				step.InputMapping = map[string]string{"repo": "some-repo"}
+
+				planFactory := atc.NewPlanFactory(0)
+
+				expectedConfig := mappedConfig
+				expectedConfig.Params = map[string]string{"FOO": "from-step"}
+
+				expectedPlan = planFactory.NewPlan(atc.DoPlan{
+					planFactory.NewPlan(atc.AggregatePlan{
+						planFactory.NewPlan(atc.GetPlan{
+							Name:    "repo",
+							Type:    "git",
+							Source:  atc.Source{"uri": "https://example.com"},
+							Version: atc.Version{"ref": "some-ref"},
+						}),
+					}),
+					planFactory.NewPlan(atc.TaskPlan{
+						Name:       "one-off",
+						Privileged: true,
+						Config:     &expectedConfig,
+					}),
+				})
+			})
+
+			It("gives the task the job's inputs under the task's names", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--job-task", "some-pipeline/some-job/unit")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(streaming, 5).Should(BeClosed())
+
+				events <- event.Status{Status: atc.StatusSucceeded}
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+
+		Context("when the step's config is invalid", func() {
+			BeforeEach(func() {
+				invalidConfig := taskConfig
+				invalidConfig.Platform = ""
+
+				(*pipelineConfig.Jobs[0].Plan[1].Do)[0].TaskConfig = &invalidConfig
+			})
+
+			It("refuses to run it", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--job-task", "some-pipeline/some-job/unit")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("invalid config for task 'unit'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when the step loads its config from a file", func() {
+			BeforeEach(func() {
+				(*pipelineConfig.Jobs[0].Plan[1].Do)[0].TaskConfig = nil
+				(*pipelineConfig.Jobs[0].Plan[1].Do)[0].TaskConfigPath = "some-repo/ci/unit.yml"
+			})
+
+			It("fails unless the input is given locally", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--job-task", "some-pipeline/some-job/unit")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("provide the 'some-repo' input locally with -i some-repo=PATH"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when the task does not exist", func() {
+			It("says so", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--job-task", "some-pipeline/some-job/bogus")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("task 'bogus' not found in job 'some-job'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when given a config too", func() {
+			It("refuses", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "--job-task", "some-pipeline/some-job/unit", "-c", filepath.Join(repoDir, "task.yml"))
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("--config and --job-task cannot be used together"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+})
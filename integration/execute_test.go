@@ -5,12 +5,14 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	"github.com/onsi/gomega/gexec"
 	"github.com/onsi/gomega/ghttp"
 	"github.com/vito/go-sse/sse"
+	"gopkg.in/yaml.v2"
 
 	"github.com/concourse/atc"
 	"github.com/concourse/atc/event"
@@ -233,6 +236,137 @@ run:
 		Expect(uploadingBits).To(BeClosed())
 	})
 
+	It("does not draw transfer progress when stderr is not a terminal", func() {
+		flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+		flyCmd.Dir = buildDir
+
+		sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(streaming).Should(BeClosed())
+		Eventually(uploadingBits).Should(BeClosed())
+
+		close(events)
+
+		<-sess.Exited
+		Expect(sess.ExitCode()).To(Equal(0))
+
+		Expect(sess.Err).NotTo(gbytes.Say("uploading fixture"))
+		Expect(sess.Err).NotTo(gbytes.Say("uploaded fixture"))
+	})
+
+	Describe("input archive caching", func() {
+		cachedArchives := func() []string {
+			archives, err := filepath.Glob(filepath.Join(homeDir, ".fly", "cache", "inputs", "*.tgz"))
+			Expect(err).NotTo(HaveOccurred())
+			return archives
+		}
+
+		run := func(args ...string) {
+			flyCmd := exec.Command(flyPath, append([]string{"-t", targetName, "e", "-c", taskConfigPath}, args...)...)
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+			Eventually(uploadingBits).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		}
+
+		It("does not keep the archive by default", func() {
+			run()
+			Expect(cachedArchives()).To(BeEmpty())
+		})
+
+		Context("with --cache", func() {
+			It("keeps the uploaded archive for the next run", func() {
+				run("--cache")
+				Expect(cachedArchives()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("when paths are excluded", func() {
+		var uploadedPaths chan []string
+
+		BeforeEach(func() {
+			err := ioutil.WriteFile(filepath.Join(buildDir, ".flyignore"), []byte("# build artefacts\n*.log\nbuild/\n!keep.log\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.MkdirAll(filepath.Join(buildDir, "build", "nested"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, file := range []string{"debug.log", "keep.log", "notes.txt", "scratch.tmp", "build/nested/out.bin"} {
+				err = ioutil.WriteFile(filepath.Join(buildDir, file), []byte("x"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		JustBeforeEach(func() {
+			uploadedPaths = make(chan []string, 1)
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id",
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/api/v1/pipes/some-pipe-id"),
+					func(w http.ResponseWriter, req *http.Request) {
+						gr, err := gzip.NewReader(req.Body)
+						Expect(err).NotTo(HaveOccurred())
+
+						tr := tar.NewReader(gr)
+
+						paths := []string{}
+						for {
+							hdr, err := tr.Next()
+							if err == io.EOF {
+								break
+							}
+
+							Expect(err).NotTo(HaveOccurred())
+
+							paths = append(paths, strings.TrimPrefix(hdr.Name, "./"))
+						}
+
+						uploadedPaths <- paths
+					},
+					ghttp.RespondWith(200, ""),
+				),
+			)
+		})
+
+		It("skips paths listed in .flyignore and given with --exclude", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--exclude", "*.tmp")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+
+			var paths []string
+			Eventually(uploadedPaths).Should(Receive(&paths))
+
+			Expect(paths).To(ContainElement("task.yml"))
+			Expect(paths).To(ContainElement("keep.log"))
+			Expect(paths).To(ContainElement("notes.txt"))
+			Expect(paths).To(ContainElement(".flyignore"))
+
+			Expect(paths).NotTo(ContainElement("debug.log"))
+			Expect(paths).NotTo(ContainElement("scratch.tmp"))
+			Expect(paths).NotTo(ContainElement("build/"))
+			Expect(paths).NotTo(ContainElement("build/nested/out.bin"))
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+	})
+
 	Context("when the build config is invalid", func() {
 		BeforeEach(func() {
 			// missing platform and run path
@@ -474,6 +608,301 @@ run:
 		})
 	})
 
+	Context("when parameters are given as flags", func() {
+		var paramsFile string
+
+		BeforeEach(func() {
+			paramsFile = filepath.Join(tmpdir, "params.yml")
+
+			err := ioutil.WriteFile(paramsFile, []byte("FOO: from-file\nBAZ: from-file\nX: 2\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			(*expectedPlan.Do)[1].Task.Config.Params = map[string]string{
+				"FOO": "from-flag",
+				"BAZ": "from-file",
+				"X":   "2",
+			}
+		})
+
+		It("overrides the params from the file, then from --param", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--params-from", paramsFile, "--param", "FOO=from-flag")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		Context("when a param in the file is a list or an object", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(paramsFile, []byte("FOO:\n  nested: [1, two]\nBAZ: [a, b]\n"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				(*expectedPlan.Do)[1].Task.Config.Params = map[string]string{
+					"FOO": `{"nested":[1,"two"]}`,
+					"BAZ": `["a","b"]`,
+					"X":   "1",
+				}
+			})
+
+			It("sets it to its JSON", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--params-from", paramsFile)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+
+		Context("when a param is not declared by the task", func() {
+			It("prints an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--param", "BOGUS=value")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unknown param `BOGUS`"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+
+			It("names every unknown param, sorted", func() {
+				paramsFile := filepath.Join(buildDir, "params.yml")
+				err := ioutil.WriteFile(paramsFile, []byte("ZED: 1\nALPHA: 2\nMIDDLE: 3\n"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--params-from", paramsFile, "--param", "BOGUS=value")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unknown params `ALPHA`, `BOGUS`, `MIDDLE`, `ZED`"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the task config is templated", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`---
+platform: some-platform
+
+image: {{image}}
+
+inputs:
+- name: fixture
+
+params:
+  FOO: {{foo}}
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			(*expectedPlan.Do)[1].Task.Config.Params["FOO"] = "from-var"
+		})
+
+		It("fills in the variables from flags and providers", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--vars-provider", "env", "--var", "foo=from-var")
+			flyCmd.Dir = buildDir
+			flyCmd.Env = append(os.Environ(), "FLY_VAR_IMAGE=ubuntu", "FLY_VAR_FOO=from-env")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+
+		Context("when a variable cannot be resolved", func() {
+			It("prints an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--vars-provider", "env", "--var", "foo=from-var")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unbound variable in template: 'image'"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the target has variable providers but no variables are given", func() {
+		BeforeEach(func() {
+			targets, err := rc.LoadTargets()
+			Expect(err).NotTo(HaveOccurred())
+
+			target := targets[targetName]
+			target.VarsProviders = []string{"env"}
+			targets[targetName] = target
+
+			payload, err := yaml.Marshal(map[string]interface{}{"targets": targets})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(userHomeDir(), ".flyrc"), payload, 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(
+				taskConfigPath,
+				[]byte(`---
+platform: some-platform
+
+image: ubuntu
+
+inputs:
+- name: fixture
+
+params:
+  FOO: ((foo))
+  BAZ: buzz
+  X: 1
+
+run:
+  path: find
+  args: [.]
+`),
+				0644,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			(*expectedPlan.Do)[1].Task.Config.Params["FOO"] = "((foo))"
+		})
+
+		It("leaves the task config as it is", func() {
+			atcServer.AllowUnhandledRequests = true
+
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+			flyCmd.Dir = buildDir
+			flyCmd.Env = append(os.Environ(), "FLY_VAR_FOO=from-env")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming, 5.0).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+	})
+
+	Context("when the image is overridden", func() {
+		Context("with --image", func() {
+			BeforeEach(func() {
+				(*expectedPlan.Do)[1].Task.Config.Image = "docker:///busybox"
+			})
+
+			It("runs the task in that image", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--image", "docker:///busybox")
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+
+		Context("with --image-resource", func() {
+			var imageResourceFile string
+
+			BeforeEach(func() {
+				imageResourceFile = filepath.Join(tmpdir, "image.yml")
+
+				err := ioutil.WriteFile(imageResourceFile, []byte("type: docker-image\nsource:\n  repository: busybox\n  tag: latest\n"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				(*expectedPlan.Do)[1].Task.Config.Image = ""
+				(*expectedPlan.Do)[1].Task.Config.ImageResource = &atc.ImageResource{
+					Type: "docker-image",
+					Source: atc.Source{
+						"repository": "busybox",
+						"tag":        "latest",
+					},
+				}
+			})
+
+			It("runs the task in the image fetched by the resource", func() {
+				atcServer.AllowUnhandledRequests = true
+
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--image-resource", imageResourceFile)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(streaming, 5.0).Should(BeClosed())
+
+				close(events)
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+
+			Context("when --image is given too", func() {
+				It("prints an error", func() {
+					flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--image-resource", imageResourceFile, "--image", "docker:///busybox")
+					flyCmd.Dir = buildDir
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess.Err).Should(gbytes.Say("only one of --image and --image-resource can be given"))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+				})
+			})
+		})
+	})
+
 	Context("when the build is interrupted", func() {
 		var aborted chan struct{}
 
@@ -568,6 +997,7 @@ run:
 			err = rc.SaveTarget(
 				rc.TargetName(targetName),
 				atcServer.URL(),
+				"main",
 				true,
 				&token,
 			)
@@ -602,6 +1032,58 @@ run:
 		})
 	})
 
+	Context("when uploading the bits fails", func() {
+		var uploadAttempts chan struct{}
+		var aborted chan struct{}
+
+		BeforeEach(func() {
+			uploadAttempts = make(chan struct{}, 10)
+			aborted = make(chan struct{})
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id",
+				func(w http.ResponseWriter, req *http.Request) {
+					ioutil.ReadAll(req.Body)
+
+					uploadAttempts <- struct{}{}
+
+					w.WriteHeader(http.StatusServiceUnavailable)
+				},
+			)
+
+			atcServer.RouteToHandler("POST", "/api/v1/builds/128/abort",
+				func(w http.ResponseWriter, r *http.Request) {
+					close(aborted)
+				},
+			)
+		})
+
+		Context("after the archive was sent", func() {
+			It("does not send it again, and aborts the build and exits 1", func() {
+				flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
+				flyCmd.Dir = buildDir
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(streaming, 5).Should(BeClosed())
+
+				Eventually(aborted, 5).Should(BeClosed())
+				Expect(uploadAttempts).To(HaveLen(1))
+
+				events <- event.Status{Status: atc.StatusAborted}
+				close(events)
+
+				Eventually(sess.Err).Should(gbytes.Say("failed to upload input 'fixture'"))
+				Expect(sess.Err).NotTo(gbytes.Say("retrying"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+
+				Expect(strings.Count(string(sess.Err.Contents()), "failed to upload input 'fixture'")).To(Equal(1))
+			})
+		})
+	})
+
 	Context("when the build succeeds", func() {
 		It("exits 0", func() {
 			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath)
@@ -661,4 +1143,108 @@ run:
 			Expect(uploadingBits).To(BeClosed())
 		})
 	})
+
+	Context("with --watch", func() {
+		var (
+			firstStarted  chan struct{}
+			firstAborted  chan struct{}
+			secondStarted chan struct{}
+		)
+
+		streamEvents := func(w http.ResponseWriter, started chan<- struct{}, until <-chan struct{}, events ...atc.Event) {
+			flusher := w.(http.Flusher)
+
+			w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+
+			close(started)
+
+			if until != nil {
+				select {
+				case <-until:
+				case <-time.After(10 * time.Second):
+				}
+			}
+
+			for id, e := range events {
+				payload, err := json.Marshal(event.Message{Event: e})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = sse.Event{ID: fmt.Sprintf("%d", id), Name: "event", Data: payload}.Write(w)
+				Expect(err).NotTo(HaveOccurred())
+
+				flusher.Flush()
+			}
+
+			err := sse.Event{Name: "end"}.Write(w)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		JustBeforeEach(func() {
+			firstStarted = make(chan struct{})
+			firstAborted = make(chan struct{})
+			secondStarted = make(chan struct{})
+
+			buildIDs := make(chan int, 2)
+			buildIDs <- 128
+			buildIDs <- 129
+
+			atcServer.RouteToHandler("POST", "/api/v1/builds",
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, `{"id":%d}`, <-buildIDs)
+				},
+			)
+
+			atcServer.RouteToHandler("PUT", "/api/v1/pipes/some-pipe-id",
+				func(w http.ResponseWriter, r *http.Request) {
+					ioutil.ReadAll(r.Body)
+				},
+			)
+
+			atcServer.RouteToHandler("GET", "/api/v1/builds/128/events",
+				func(w http.ResponseWriter, r *http.Request) {
+					streamEvents(w, firstStarted, firstAborted, event.Status{Status: atc.StatusAborted})
+				},
+			)
+
+			atcServer.RouteToHandler("POST", "/api/v1/builds/128/abort",
+				func(w http.ResponseWriter, r *http.Request) {
+					close(firstAborted)
+				},
+			)
+
+			atcServer.RouteToHandler("GET", "/api/v1/builds/129/events",
+				func(w http.ResponseWriter, r *http.Request) {
+					streamEvents(w, secondStarted, nil, event.Status{Status: atc.StatusSucceeded})
+				},
+			)
+		})
+
+		It("aborts the running build when an input changes, and runs the task again", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "e", "-c", taskConfigPath, "--watch", "--watch-interval", "100ms")
+			flyCmd.Dir = buildDir
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(firstStarted, 5).Should(BeClosed())
+			Eventually(sess.Out).Should(gbytes.Say("executing build 128"))
+
+			err = ioutil.WriteFile(filepath.Join(buildDir, "new-file"), []byte("changed"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(firstAborted, 5).Should(BeClosed())
+			Eventually(sess.Err).Should(gbytes.Say("change detected; aborting build 128"))
+
+			Eventually(secondStarted, 5).Should(BeClosed())
+			Eventually(sess.Out).Should(gbytes.Say("executing build 129"))
+			Eventually(sess.Err, 5).Should(gbytes.Say("waiting for changes"))
+
+			sess.Interrupt()
+
+			Eventually(sess, 5).Should(gexec.Exit(0))
+		})
+	})
 })
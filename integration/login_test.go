@@ -541,4 +541,48 @@ var _ = Describe("login Command", func() {
 			})
 		})
 	})
+
+	Describe("login with a team name", func() {
+		var (
+			flyCmd *exec.Cmd
+		)
+
+		BeforeEach(func() {
+			atcServer = ghttp.NewServer()
+			flyCmd = exec.Command(flyPath, "-t", "some-target", "login", "-c", atcServer.URL(), "--team-name", "some-team")
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/teams/some-team/auth/methods"),
+					ghttp.RespondWithJSONEncoded(200, []atc.AuthMethod{}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/teams/some-team/pipelines"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{
+						{Name: "team-pipeline"},
+					}),
+				),
+			)
+		})
+
+		It("authenticates against the team and scopes later commands to it", func() {
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Out).Should(gbytes.Say("target saved"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(0))
+
+			otherCmd := exec.Command(flyPath, "-t", "some-target", "pipelines")
+
+			sess, err = gexec.Start(otherCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			<-sess.Exited
+
+			Expect(sess).To(gbytes.Say("team-pipeline"))
+			Expect(sess.ExitCode()).To(Equal(0))
+		})
+	})
 })
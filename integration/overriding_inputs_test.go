@@ -30,6 +30,7 @@ var _ = Describe("Fly CLI", func() {
 	var events chan atc.Event
 	var uploading chan struct{}
 
+	var otherInputVersion atc.Version
 	var expectedPlan atc.Plan
 
 	BeforeEach(func() {
@@ -75,6 +76,10 @@ run:
 		streaming = make(chan struct{})
 		events = make(chan atc.Event)
 
+		otherInputVersion = atc.Version{"some": "other-version"}
+	})
+
+	JustBeforeEach(func() {
 		planFactory := atc.NewPlanFactory(0)
 
 		expectedPlan = planFactory.NewPlan(atc.DoPlan{
@@ -91,7 +96,7 @@ run:
 					Type:    "git",
 					Source:  atc.Source{"uri": "https://example.com"},
 					Params:  atc.Params{"some": "other-params"},
-					Version: atc.Version{"some": "other-version"},
+					Version: otherInputVersion,
 					Tags:    atc.Tags{"tag-1", "tag-2"},
 				}),
 			}),
@@ -116,9 +121,7 @@ run:
 				},
 			}),
 		})
-	})
 
-	JustBeforeEach(func() {
 		uploading = make(chan struct{})
 
 		atcServer.RouteToHandler("POST", "/api/v1/pipes",
@@ -262,4 +265,132 @@ run:
 		<-sess.Exited
 		Expect(sess).To(gexec.Exit(0))
 	})
+
+	Context("when taking the inputs from a build of the job", func() {
+		BeforeEach(func() {
+			otherInputVersion = atc.Version{"some": "older-version"}
+
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/builds/3",
+				ghttp.RespondWithJSONEncoded(http.StatusOK, atc.Build{ID: 42, Name: "3"}),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/builds/42/resources",
+				ghttp.RespondWithJSONEncoded(http.StatusOK, atc.BuildInputsOutputs{
+					Inputs: []atc.PublicBuildInput{
+						{
+							Name:     "some-input",
+							Resource: "some-resource",
+							Type:     "git",
+							Version:  atc.Version{"some": "older-input-version"},
+						},
+						{
+							Name:     "some-other-input",
+							Resource: "some-other-resource",
+							Type:     "git",
+							Version:  atc.Version{"some": "older-version"},
+						},
+					},
+				}),
+			)
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/config",
+				ghttp.RespondWithJSONEncoded(http.StatusOK, atc.Config{
+					Resources: atc.ResourceConfigs{
+						{Name: "some-resource", Type: "git", Source: atc.Source{"uri": "https://internet.com"}},
+						{Name: "some-other-resource", Type: "git", Source: atc.Source{"uri": "https://example.com"}},
+					},
+					Jobs: atc.JobConfigs{
+						{
+							Name: "some-job",
+							Plan: atc.PlanSequence{
+								{
+									Aggregate: &atc.PlanSequence{
+										{Get: "some-input", Resource: "some-resource"},
+										{
+											Get:      "some-other-input",
+											Resource: "some-other-resource",
+											Params:   atc.Params{"some": "other-params"},
+											Tags:     atc.Tags{"tag-1", "tag-2"},
+										},
+									},
+								},
+							},
+						},
+					},
+				}, http.Header{atc.ConfigVersionHeader: {"42"}}),
+			)
+		})
+
+		JustBeforeEach(func() {
+			// the inputs of the job's next build may not even be resolvable
+			atcServer.RouteToHandler("GET", "/api/v1/pipelines/some-pipeline/jobs/some-job/inputs",
+				ghttp.RespondWith(http.StatusInternalServerError, ""),
+			)
+		})
+
+		It("gets the versions that the build used", func() {
+			flyCmd := exec.Command(
+				flyPath, "-t", targetName, "e",
+				"--inputs-from-build", "some-pipeline/some-job/3",
+				"--input", fmt.Sprintf("some-input=%s", buildDir),
+				"--config", filepath.Join(buildDir, "task.yml"),
+			)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+			Eventually(uploading).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess).To(gexec.Exit(0))
+		})
+	})
+
+	Context("when pinning the version of an input", func() {
+		BeforeEach(func() {
+			otherInputVersion = atc.Version{"some": "pinned-version"}
+		})
+
+		It("gets that version", func() {
+			flyCmd := exec.Command(
+				flyPath, "-t", targetName, "e",
+				"--inputs-from", "some-pipeline/some-job",
+				"--input", fmt.Sprintf("some-input=%s", buildDir),
+				"--input-version", "some-other-input=some:pinned-version",
+				"--config", filepath.Join(buildDir, "task.yml"),
+			)
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(streaming).Should(BeClosed())
+			Eventually(uploading).Should(BeClosed())
+
+			close(events)
+
+			<-sess.Exited
+			Expect(sess).To(gexec.Exit(0))
+		})
+
+		Context("when the input is not taken from the job", func() {
+			It("errors", func() {
+				flyCmd := exec.Command(
+					flyPath, "-t", targetName, "e",
+					"--inputs-from", "some-pipeline/some-job",
+					"--input", fmt.Sprintf("some-input=%s", buildDir),
+					"--input-version", "bogus=some:version",
+					"--config", filepath.Join(buildDir, "task.yml"),
+				)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("cannot pin the version of input `bogus`"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
 })
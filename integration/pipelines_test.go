@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -52,6 +53,28 @@ var _ = Describe("Fly CLI", func() {
 
 				Expect(flyCmd).To(HaveExited(0))
 			})
+
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--json")
+				})
+
+				It("prints the pipelines as a JSON array", func() {
+					sess, err := gexec.Start(flyCmd, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(sess).Should(gexec.Exit(0))
+
+					var pipelines []atc.Pipeline
+					err = json.Unmarshal(sess.Out.Contents(), &pipelines)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(pipelines).To(Equal([]atc.Pipeline{
+						{Name: "pipeline-1-longer", URL: "/pipelines/pipeline-1", Paused: false},
+						{Name: "pipeline-2", URL: "/pipelines/pipeline-2", Paused: true},
+						{Name: "pipeline-3", URL: "/pipelines/pipeline-3", Paused: false},
+					}))
+				})
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
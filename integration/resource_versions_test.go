@@ -0,0 +1,112 @@
+package integration_test
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("resource-versions", func() {
+		var (
+			flyCmd *exec.Cmd
+		)
+
+		pipelineName := "mypipeline"
+		resourceName := "myresource"
+		fullResourceName := fmt.Sprintf("%s/%s", pipelineName, resourceName)
+		versionsPath := fmt.Sprintf("/api/v1/pipelines/%s/resources/%s/versions", pipelineName, resourceName)
+
+		BeforeEach(func() {
+			flyCmd = exec.Command(flyPath, "-t", targetName, "resource-versions", "-r", fullResourceName)
+		})
+
+		Context("when versions are returned from the API", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "limit=50"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.VersionedResource{
+							{ID: 3, Version: atc.Version{"ref": "ccc", "branch": "master"}, Enabled: true},
+							{ID: 2, Version: atc.Version{"ref": "bbb"}, Enabled: false},
+							{ID: 1, Version: atc.Version{"ref": "aaa"}, Enabled: true},
+						}),
+					),
+				)
+			})
+
+			It("lists them to the user", func() {
+				Expect(flyCmd).To(PrintTable(ui.Table{
+					Headers: ui.TableRow{
+						{Contents: "id", Color: color.New(color.Bold)},
+						{Contents: "version", Color: color.New(color.Bold)},
+						{Contents: "enabled", Color: color.New(color.Bold)},
+					},
+					Data: []ui.TableRow{
+						{{Contents: "3"}, {Contents: "branch: master, ref: ccc"}, {Contents: "yes"}},
+						{{Contents: "2"}, {Contents: "ref: bbb"}, {Contents: "no", Color: color.New(color.FgRed)}},
+						{{Contents: "1"}, {Contents: "ref: aaa"}, {Contents: "yes"}},
+					},
+				}))
+
+				Expect(flyCmd).To(HaveExited(0))
+			})
+		})
+
+		Context("when paginating", func() {
+			BeforeEach(func() {
+				flyCmd.Args = append(flyCmd.Args, "--count", "2", "--until", "3")
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath, "until=3&limit=2"),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, []atc.VersionedResource{
+							{ID: 2, Version: atc.Version{"ref": "bbb"}, Enabled: true},
+							{ID: 1, Version: atc.Version{"ref": "aaa"}, Enabled: true},
+						}),
+					),
+				)
+			})
+
+			It("requests the given page", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gbytes.Say("ref: bbb"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+		})
+
+		Context("when the resource does not exist", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", versionsPath),
+						ghttp.RespondWith(http.StatusNotFound, nil),
+					),
+				)
+			})
+
+			It("exits 1 and outputs an error", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("pipeline/resource not found"))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -226,6 +227,81 @@ var _ = Describe("Fly CLI", func() {
 					})
 				})
 			})
+			Context("when the templated variables are secret", func() {
+				BeforeEach(func() {
+					path, err := atc.Routes.CreatePathForRoute(atc.GetConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
+					Expect(err).NotTo(HaveOccurred())
+
+					existingConfig := config
+					existingConfig.Resources = atc.ResourceConfigs{
+						config.Resources[0],
+						{
+							Name: "some-other-resource",
+							Type: "some-other-type",
+							Source: atc.Source{
+								"secret_key": "oldsecret",
+							},
+						},
+					}
+
+					atcServer.RouteToHandler("GET", path,
+						ghttp.RespondWithJSONEncoded(http.StatusOK, existingConfig, http.Header{atc.ConfigVersionHeader: {"42"}}),
+					)
+				})
+
+				It("hides their values, and the values they replace, in the diff", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testConfig.yml",
+						"--var", "resource-key=verysecret",
+						"--load-vars-from", "fixtures/vars.yml",
+						"--secret-var", "*-key",
+						"--dry-run",
+					)
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say(`resources\.some-other-resource\.source\.secret_key has changed`))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(2))
+
+					Expect(sess.Out.Contents()).To(ContainSubstring("((redacted))"))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("verysecret"))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("oldsecret"))
+				})
+
+				It("hides the values resolved from providers without being told to", func() {
+					flyCmd := exec.Command(
+						flyPath, "-t", targetName,
+						"set-pipeline",
+						"--pipeline", "awesome-pipeline",
+						"-c", "fixtures/testConfig.yml",
+						"--vars-provider", "env",
+						"--dry-run",
+					)
+					flyCmd.Env = append(
+						os.Environ(),
+						"FLY_VAR_RESOURCE_TYPE=template-type",
+						"FLY_VAR_RESOURCE_KEY=verysecret",
+					)
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say(`resources\.some-other-resource\.source\.secret_key has changed`))
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(2))
+
+					Expect(sess.Out.Contents()).To(ContainSubstring("((redacted))"))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("verysecret"))
+					Expect(sess.Out.Contents()).NotTo(ContainSubstring("oldsecret"))
+				})
+			})
 		})
 
 		Describe("setting", func() {
@@ -357,42 +433,28 @@ var _ = Describe("Fly CLI", func() {
 					Expect(err).NotTo(HaveOccurred())
 
 					Eventually(sess).Should(gbytes.Say("group some-group has changed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("- some-new-job", "green")))
-
-					Eventually(sess).Should(gbytes.Say("group some-other-group has been removed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-other-group", "red")))
+					Eventually(sess).Should(gbytes.Say(`groups\.some-group\.jobs\[2\] has been added`))
+					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("some-new-job", "green")))
 
-					Eventually(sess).Should(gbytes.Say("group some-new-group has been added"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-new-group", "green")))
+					Eventually(sess).Should(gbytes.Say("group some-other-group has been renamed to some-new-group"))
 
 					Eventually(sess).Should(gbytes.Say("resource some-resource has changed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("type: some-type", "red")))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("type: some-new-type", "green")))
+					Eventually(sess).Should(gbytes.Say(`resources\.some-resource\.type has changed`))
+					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("some-type", "red")))
+					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("some-new-type", "green")))
 
-					Eventually(sess).Should(gbytes.Say("resource some-other-resource has been removed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-other-resource", "red")))
-
-					Eventually(sess).Should(gbytes.Say("resource some-new-resource has been added"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-new-resource", "green")))
+					Eventually(sess).Should(gbytes.Say("resource some-other-resource has been renamed to some-new-resource"))
 
 					Eventually(sess).Should(gbytes.Say("resource type some-resource-type has changed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("type: some-type", "red")))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("type: some-new-type", "green")))
-
-					Eventually(sess).Should(gbytes.Say("resource type some-other-resource-type has been removed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-other-resource-type", "red")))
+					Eventually(sess).Should(gbytes.Say(`resource_types\.some-resource-type\.type has changed`))
 
-					Eventually(sess).Should(gbytes.Say("resource type some-new-resource-type has been added"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-new-resource-type", "green")))
+					Eventually(sess).Should(gbytes.Say("resource type some-other-resource-type has been renamed to some-new-resource-type"))
 
 					Eventually(sess).Should(gbytes.Say("job some-job has changed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("serial: true", "red")))
-
-					Eventually(sess).Should(gbytes.Say("job some-other-job has been removed"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-other-job", "red")))
+					Eventually(sess).Should(gbytes.Say(`jobs\.some-job\.serial has`))
+					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("true", "red")))
 
-					Eventually(sess).Should(gbytes.Say("job some-new-job has been added"))
-					Eventually(sess.Out.Contents).Should(ContainSubstring(ansi.Color("name: some-new-job", "green")))
+					Eventually(sess).Should(gbytes.Say("job some-other-job has been renamed to some-new-job"))
 
 					Eventually(sess).Should(gbytes.Say(`apply configuration\? \[yN\]: `))
 					yes(stdin)
@@ -429,6 +491,95 @@ var _ = Describe("Fly CLI", func() {
 				})
 			})
 
+			Context("when doing a dry run", func() {
+				Context("when the config has changed", func() {
+					BeforeEach(func() {
+						changedConfig.Jobs = append(atc.JobConfigs{}, changedConfig.Jobs...)
+						changedConfig.Jobs[0].Serial = false
+					})
+
+					It("shows the changes and exits 2 without applying them", func() {
+						reqsBefore := len(atcServer.ReceivedRequests())
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--dry-run")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess).Should(gbytes.Say("job some-job has changed"))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(2))
+
+						Expect(sess.Out.Contents()).ToNot(ContainSubstring("apply configuration?"))
+						Expect(atcServer.ReceivedRequests()).To(HaveLen(reqsBefore + 1))
+					})
+
+					It("can show the changes as JSON", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--dry-run", "--diff-format", "json")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(2))
+
+						var diffs map[string][]map[string]interface{}
+						err = json.Unmarshal(sess.Out.Contents(), &diffs)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(diffs["jobs"]).To(HaveLen(1))
+						Expect(diffs["jobs"][0]["name"]).To(Equal("some-job"))
+						Expect(diffs["jobs"][0]["action"]).To(Equal("changed"))
+					})
+
+					It("can show the changes as a patch", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--dry-run", "--diff-format", "patch")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(2))
+
+						Expect(sess.Out).To(gbytes.Say(`--- a/jobs/some-job`))
+						Expect(sess.Out).To(gbytes.Say(`\+\+\+ b/jobs/some-job`))
+						Expect(sess.Out).To(gbytes.Say(`-serial: true`))
+					})
+
+					It("refuses to show the changes as JSON or a patch unless doing a dry run", func() {
+						for _, format := range []string{"json", "patch"} {
+							reqsBefore := len(atcServer.ReceivedRequests())
+							flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--diff-format", format)
+
+							sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+							Expect(err).NotTo(HaveOccurred())
+
+							Eventually(sess.Err).Should(gbytes.Say("--diff-format " + format + " can only be used with --dry-run"))
+
+							<-sess.Exited
+							Expect(sess.ExitCode()).To(Equal(1))
+
+							Expect(sess.Out.Contents()).To(BeEmpty())
+							Expect(atcServer.ReceivedRequests()).To(HaveLen(reqsBefore))
+						}
+					})
+				})
+
+				Context("when the config has not changed", func() {
+					It("says so and exits 0", func() {
+						flyCmd := exec.Command(flyPath, "-t", targetName, "set-pipeline", "-p", "awesome-pipeline", "-c", configFile.Name(), "--dry-run")
+
+						sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+						Expect(err).NotTo(HaveOccurred())
+
+						Eventually(sess.Err).Should(gbytes.Say("no changes to apply"))
+
+						<-sess.Exited
+						Expect(sess.ExitCode()).To(Equal(0))
+					})
+				})
+			})
+
 			Context("when configuring fails", func() {
 				BeforeEach(func() {
 					path, err := atc.Routes.CreatePathForRoute(atc.SaveConfig, rata.Params{"pipeline_name": "awesome-pipeline"})
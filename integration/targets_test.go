@@ -0,0 +1,142 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"os/exec"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("targets", func() {
+		It("lists the saved targets", func() {
+			flyCmd := exec.Command(flyPath, "targets")
+
+			Expect(flyCmd).To(PrintTable(ui.Table{
+				Headers: ui.TableRow{
+					{Contents: "name", Color: color.New(color.Bold)},
+					{Contents: "url", Color: color.New(color.Bold)},
+					{Contents: "team", Color: color.New(color.Bold)},
+					{Contents: "insecure", Color: color.New(color.Bold)},
+					{Contents: "token", Color: color.New(color.Bold)},
+				},
+				Data: []ui.TableRow{
+					{{Contents: targetName}, {Contents: atcServer.URL()}, {Contents: "main"}, {Contents: "no"}, {Contents: "none", Color: color.New(color.Faint)}},
+				},
+			}))
+		})
+
+		It("shows the status of each target's token", func() {
+			err := rc.SaveTarget("opaque", atcServer.URL(), "main", false, &rc.TargetToken{Type: "Bearer", Value: "some-token"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("expired", atcServer.URL(), "main", false, &rc.TargetToken{Type: "Bearer", Value: "some-token", Expiry: time.Now().Add(-time.Minute).Unix()})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = rc.SaveTarget("fresh", atcServer.URL(), "main", false, &rc.TargetToken{Type: "Bearer", Value: "some-token", Expiry: time.Now().Add(24 * time.Hour).Unix()})
+			Expect(err).NotTo(HaveOccurred())
+
+			flyCmd := exec.Command(flyPath, "targets")
+
+			Expect(flyCmd).To(PrintTable(ui.Table{
+				Data: []ui.TableRow{
+					{{Contents: "expired"}, {Contents: atcServer.URL()}, {Contents: "main"}, {Contents: "no"}, {Contents: "expired", Color: ui.FailedColor}},
+					{{Contents: "fresh"}, {Contents: atcServer.URL()}, {Contents: "main"}, {Contents: "no"}, {Contents: "valid"}},
+					{{Contents: "opaque"}, {Contents: atcServer.URL()}, {Contents: "main"}, {Contents: "no"}, {Contents: "unknown", Color: color.New(color.Faint)}},
+					{{Contents: targetName}, {Contents: atcServer.URL()}, {Contents: "main"}, {Contents: "no"}, {Contents: "none", Color: color.New(color.Faint)}},
+				},
+			}))
+		})
+
+		Context("when --json is given", func() {
+			It("prints the targets as a JSON array, without their tokens", func() {
+				err := rc.SaveTarget("fresh", atcServer.URL(), "main", true, &rc.TargetToken{Type: "Bearer", Value: "some-token", Expiry: time.Now().Add(24 * time.Hour).Unix()})
+				Expect(err).NotTo(HaveOccurred())
+
+				sess, err := gexec.Start(exec.Command(flyPath, "targets", "--json"), nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess).Should(gexec.Exit(0))
+
+				Expect(sess.Out.Contents()).NotTo(ContainSubstring("some-token"))
+
+				var targets []map[string]interface{}
+				err = json.Unmarshal(sess.Out.Contents(), &targets)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(targets).To(Equal([]map[string]interface{}{
+					{"name": "fresh", "url": atcServer.URL(), "team": "main", "insecure": true, "token": "valid"},
+					{"name": targetName, "url": atcServer.URL(), "team": "main", "insecure": false, "token": "none"},
+				}))
+			})
+		})
+	})
+
+	Describe("rename-target", func() {
+		It("renames the target", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "rename-target", "-n", "renamed-target")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gbytes.Say("renamed target '" + targetName + "' to 'renamed-target'"))
+			Eventually(sess).Should(gexec.Exit(0))
+
+			Expect(exec.Command(flyPath, "targets")).To(PrintTable(ui.Table{
+				Data: []ui.TableRow{
+					{{Contents: "renamed-target"}, {Contents: atcServer.URL()}, {Contents: "main"}, {Contents: "no"}, {Contents: "none", Color: color.New(color.Faint)}},
+				},
+			}))
+		})
+	})
+
+	Describe("delete-target", func() {
+		It("deletes the target", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "delete-target")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gbytes.Say("deleted target '" + targetName + "'"))
+			Eventually(sess).Should(gexec.Exit(0))
+
+			otherCmd := exec.Command(flyPath, "-t", targetName, "pipelines")
+
+			sess, err = gexec.Start(otherCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("unknown target: " + targetName))
+			Eventually(sess).Should(gexec.Exit(1))
+		})
+
+		Context("when the target does not exist", func() {
+			It("exits 1 and outputs an error", func() {
+				flyCmd := exec.Command(flyPath, "-t", "bogus", "delete-target")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say("unknown target: bogus"))
+				Eventually(sess).Should(gexec.Exit(1))
+			})
+		})
+	})
+
+	Describe("logout", func() {
+		It("logs out of the target", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "logout")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess).Should(gbytes.Say("logged out of target '" + targetName + "'"))
+			Eventually(sess).Should(gexec.Exit(0))
+		})
+	})
+})
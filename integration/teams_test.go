@@ -0,0 +1,82 @@
+package integration_test
+
+import (
+	"os/exec"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/ui"
+	"github.com/fatih/color"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("teams", func() {
+		var (
+			flyCmd *exec.Cmd
+		)
+
+		BeforeEach(func() {
+			flyCmd = exec.Command(flyPath, "-t", targetName, "teams")
+		})
+
+		Context("when teams are returned from the API", func() {
+			BeforeEach(func() {
+				team := atc.Team{Name: "platform"}
+				team.BasicAuth.BasicAuthUsername = "admin"
+				team.GitHubAuth.ClientID = "some-client-id"
+
+				basicTeam := atc.Team{Name: "main"}
+				basicTeam.BasicAuth.BasicAuthUsername = "admin"
+
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/teams"),
+						ghttp.RespondWithJSONEncoded(200, []atc.Team{
+							team,
+							basicTeam,
+							{Name: "open"},
+						}),
+					),
+				)
+			})
+
+			It("lists them to the user, ordered by name", func() {
+				Expect(flyCmd).To(PrintTable(ui.Table{
+					Headers: ui.TableRow{
+						{Contents: "name", Color: color.New(color.Bold)},
+						{Contents: "auth", Color: color.New(color.Bold)},
+					},
+					Data: []ui.TableRow{
+						{{Contents: "main"}, {Contents: "basic"}},
+						{{Contents: "open"}, {Contents: "none", Color: color.New(color.Faint)}},
+						{{Contents: "platform"}, {Contents: "basic, github"}},
+					},
+				}))
+
+				Expect(flyCmd).To(HaveExited(0))
+			})
+		})
+
+		Context("and the api returns an internal server error", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/api/v1/teams"),
+						ghttp.RespondWith(500, ""),
+					),
+				)
+			})
+
+			It("writes an error message to stderr", func() {
+				sess, err := gexec.Start(flyCmd, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Eventually(sess.Err).Should(gbytes.Say("Unexpected Response"))
+				Eventually(sess).Should(gexec.Exit(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,93 @@
+package integration_test
+
+import (
+	"os/exec"
+	"time"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("token expiry", func() {
+		var (
+			flyCmd *exec.Cmd
+			expiry time.Time
+		)
+
+		BeforeEach(func() {
+			flyCmd = exec.Command(flyPath, "-t", targetName, "containers")
+
+			atcServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/containers"),
+					ghttp.RespondWithJSONEncoded(200, []atc.Container{}),
+				),
+			)
+		})
+
+		JustBeforeEach(func() {
+			err := rc.SaveTarget(
+				targetName,
+				atcServer.URL(),
+				"main",
+				false,
+				&rc.TargetToken{Type: "Bearer", Value: "some-token", Expiry: expiry.Unix()},
+			)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the token expires soon", func() {
+			BeforeEach(func() {
+				expiry = time.Now().Add(30*time.Minute + 30*time.Second)
+			})
+
+			It("warns that it is expiring and still runs the command", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say("warning: your token for target '" + targetName + "' expires in 30m0s\\. run `fly -t " + targetName + " login` to renew it"))
+			})
+		})
+
+		Context("when the token has expired", func() {
+			BeforeEach(func() {
+				expiry = time.Now().Add(-time.Minute)
+			})
+
+			It("warns that it has expired", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).To(gbytes.Say("warning: your token for target '" + targetName + "' has expired\\. run `fly -t " + targetName + " login` to renew it"))
+			})
+		})
+
+		Context("when the token expires well after the warning period", func() {
+			BeforeEach(func() {
+				expiry = time.Now().Add(rc.TokenExpiryWarning + time.Hour)
+			})
+
+			It("does not warn", func() {
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+
+				Expect(sess.Err).NotTo(gbytes.Say("warning"))
+			})
+		})
+	})
+})
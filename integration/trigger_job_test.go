@@ -0,0 +1,166 @@
+package integration_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/vito/go-sse/sse"
+
+	"github.com/concourse/atc"
+	"github.com/concourse/atc/event"
+)
+
+var _ = Describe("Fly CLI", func() {
+	Describe("trigger-job", func() {
+		var (
+			flyCmd    *exec.Cmd
+			streaming chan struct{}
+			events    chan atc.Event
+		)
+
+		pipelineName := "awesome-pipeline"
+		jobName := "awesome-job"
+		fullJobName := fmt.Sprintf("%s/%s", pipelineName, jobName)
+		apiPath := fmt.Sprintf("/api/v1/pipelines/%s/jobs/%s/builds", pipelineName, jobName)
+
+		BeforeEach(func() {
+			streaming = make(chan struct{})
+			events = make(chan atc.Event)
+		})
+
+		Context("when the job is triggered", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", apiPath),
+						ghttp.RespondWithJSONEncoded(http.StatusOK, atc.Build{ID: 57, Name: "42"}),
+					),
+				)
+			})
+
+			It("prints the name of the started build", func() {
+				flyCmd = exec.Command(flyPath, "-t", targetName, "trigger-job", "-j", fullJobName)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess).Should(gbytes.Say(fmt.Sprintf("started %s #42", fullJobName)))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(0))
+			})
+
+			Context("when --watch is given", func() {
+				BeforeEach(func() {
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/builds/57/events"),
+							func(w http.ResponseWriter, r *http.Request) {
+								flusher := w.(http.Flusher)
+
+								w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+								w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
+								w.Header().Add("Connection", "keep-alive")
+
+								w.WriteHeader(http.StatusOK)
+
+								flusher.Flush()
+
+								close(streaming)
+
+								id := 0
+
+								for e := range events {
+									payload, err := json.Marshal(event.Message{Event: e})
+									Expect(err).NotTo(HaveOccurred())
+
+									event := sse.Event{
+										ID:   fmt.Sprintf("%d", id),
+										Name: "event",
+										Data: payload,
+									}
+
+									err = event.Write(w)
+									Expect(err).NotTo(HaveOccurred())
+
+									flusher.Flush()
+
+									id++
+								}
+
+								err := sse.Event{
+									Name: "end",
+								}.Write(w)
+								Expect(err).NotTo(HaveOccurred())
+							},
+						),
+					)
+				})
+
+				It("streams the build and exits with its result", func() {
+					flyCmd = exec.Command(flyPath, "-t", targetName, "trigger-job", "-j", fullJobName, "--watch")
+
+					sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(sess).Should(gbytes.Say(fmt.Sprintf("started %s #42", fullJobName)))
+
+					Eventually(streaming).Should(BeClosed())
+
+					events <- event.Log{Payload: "sup"}
+					Eventually(sess.Out).Should(gbytes.Say("sup"))
+
+					events <- event.Status{Status: atc.StatusFailed}
+					close(events)
+
+					<-sess.Exited
+					Expect(sess.ExitCode()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the job does not exist", func() {
+			BeforeEach(func() {
+				atcServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", apiPath),
+						ghttp.RespondWith(http.StatusNotFound, nil),
+					),
+				)
+			})
+
+			It("exits 1 and outputs an error", func() {
+				flyCmd = exec.Command(flyPath, "-t", targetName, "trigger-job", "-j", fullJobName)
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say(`error`))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+
+		Context("when the job flag is not provided", func() {
+			It("exits 1 and outputs an error", func() {
+				flyCmd = exec.Command(flyPath, "-t", targetName, "trigger-job")
+
+				sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(sess.Err).Should(gbytes.Say(`error`))
+
+				<-sess.Exited
+				Expect(sess.ExitCode()).To(Equal(1))
+			})
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -87,6 +88,27 @@ var _ = Describe("Fly CLI", func() {
 
 				Expect(flyCmd).To(HaveExited(0))
 			})
+
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--json")
+				})
+
+				It("prints the volumes as a JSON array, ordered by worker name and volume name", func() {
+					sess, err := gexec.Start(flyCmd, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(sess).Should(gexec.Exit(0))
+
+					var volumes []atc.Volume
+					err = json.Unmarshal(sess.Out.Contents(), &volumes)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(volumes).To(HaveLen(5))
+					Expect(volumes[0].ID).To(Equal("aaabbb"))
+					Expect(volumes[0].ResourceVersion).To(Equal(atc.Version{"version": "two", "another": "field"}))
+					Expect(volumes[4].ID).To(Equal("eeeeee"))
+				})
+			})
 		})
 
 		Context("and the api returns an internal server error", func() {
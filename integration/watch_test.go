@@ -211,4 +211,18 @@ var _ = Describe("Watching", func() {
 			})
 		})
 	})
+
+	Context("when log prefixes are asked for along with jsonl output", func() {
+		It("returns an error and exits", func() {
+			flyCmd := exec.Command(flyPath, "-t", targetName, "watch", "--output-format", "jsonl", "--timestamps", "wall")
+
+			sess, err := gexec.Start(flyCmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(sess.Err).Should(gbytes.Say("--timestamps and --step-names cannot be used with --output-format jsonl"))
+
+			<-sess.Exited
+			Expect(sess.ExitCode()).To(Equal(1))
+		})
+	})
 })
@@ -1,6 +1,7 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os/exec"
 
 	"github.com/concourse/atc"
@@ -81,6 +82,28 @@ var _ = Describe("Fly CLI", func() {
 				Expect(flyCmd).To(HaveExited(0))
 			})
 
+			Context("when --json is given", func() {
+				BeforeEach(func() {
+					flyCmd.Args = append(flyCmd.Args, "--json")
+				})
+
+				It("prints the workers as a JSON array, ordered by name", func() {
+					sess, err := gexec.Start(flyCmd, nil, nil)
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(sess).Should(gexec.Exit(0))
+
+					var workers []atc.Worker
+					err = json.Unmarshal(sess.Out.Contents(), &workers)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(workers).To(HaveLen(3))
+					Expect(workers[0].Name).To(Equal("worker-1"))
+					Expect(workers[0].BaggageclaimURL).To(Equal("http://2.2.3.4:7788"))
+					Expect(workers[1].Name).To(Equal("worker-2"))
+					Expect(workers[2].Name).To(Equal("worker-3"))
+				})
+			})
+
 			Context("when --details is given", func() {
 				BeforeEach(func() {
 					flyCmd.Args = append(flyCmd.Args, "--details")
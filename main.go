@@ -11,15 +11,26 @@ import (
 	"github.com/concourse/go-concourse/concourse"
 	"github.com/fatih/color"
 	"github.com/jessevdk/go-flags"
+	"github.com/mattn/go-isatty"
+	"github.com/vito/go-interact/interact"
 )
 
 func main() {
-	parser := flags.NewParser(&commands.Fly, flags.HelpFlag|flags.PassDoubleDash)
-	parser.NamespaceDelimiter = "-"
+	parser := newParser()
 
 	embolden := color.New(color.Bold).SprintfFunc()
 
 	_, err := parser.Parse()
+	if err == concourse.ErrUnauthorized && canReauthenticate(parser) {
+		if reauthenticate() {
+			// start over with fresh options, since parsing again would add to
+			// those that can be given more than once
+			commands.Fly = commands.FlyCommand{Version: commands.Fly.Version}
+
+			_, err = newParser().Parse()
+		}
+	}
+
 	if err != nil {
 		if err == concourse.ErrUnauthorized {
 			fmt.Fprintln(os.Stderr, "not authorized. run the following to log in:")
@@ -46,6 +57,59 @@ func main() {
 	}
 }
 
+func newParser() *flags.Parser {
+	parser := flags.NewParser(&commands.Fly, flags.HelpFlag|flags.PassDoubleDash)
+	parser.NamespaceDelimiter = "-"
+
+	return parser
+}
+
+func canReauthenticate(parser *flags.Parser) bool {
+	if commands.Fly.Target == "" {
+		return false
+	}
+
+	if parser.Active != nil && parser.Active.Name == "login" {
+		return false
+	}
+
+	return isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// reauthenticate offers to run the login flow for the current target, so that
+// the failed command can be retried with a fresh token.
+func reauthenticate() bool {
+	fmt.Fprintf(os.Stderr, "not authorized for target %s.\n\n", commands.Fly.Target)
+
+	confirm := false
+	err := interact.NewInteraction("log in again and retry?").Resolve(&confirm)
+	if err != nil || !confirm {
+		return false
+	}
+
+	target, err := rc.SelectTarget(commands.Fly.Target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "login failed: %s\n", err)
+		return false
+	}
+
+	// log in just as the target was, so that its settings are kept
+	login := commands.LoginCommand{
+		TeamName: target.TeamName,
+		Insecure: target.Insecure,
+	}
+
+	err = login.Execute(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "login failed: %s\n", err)
+		return false
+	}
+
+	fmt.Println("")
+
+	return true
+}
+
 func isURL(passedURL string) bool {
 	matched, _ := regexp.MatchString("^http[s]?://", passedURL)
 	return matched
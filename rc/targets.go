@@ -23,6 +23,8 @@ import (
 
 var ErrNoTargetSpecified = errors.New("no target specified")
 
+const DefaultTeamName = "main"
+
 type UnknownTargetError struct {
 	TargetName TargetName
 }
@@ -33,28 +35,47 @@ func (err UnknownTargetError) Error() string {
 
 type TargetProps struct {
 	API      string       `yaml:"api"`
+	TeamName string       `yaml:"team,omitempty"`
 	Insecure bool         `yaml:"insecure,omitempty"`
 	Token    *TargetToken `yaml:"token,omitempty"`
+
+	// SecretVars are patterns, like '*_password', for the names of template
+	// variables whose values set-pipeline should not show.
+	SecretVars []string `yaml:"secret_vars,omitempty"`
+
+	// VarsProviders are specs, like 'env' or 'command:pass-vars', for where
+	// template variables are resolved from when not given by flags.
+	VarsProviders []string `yaml:"vars_providers,omitempty"`
 }
 
 type TargetToken struct {
-	Type  string `yaml:"type"`
-	Value string `yaml:"value"`
+	Type   string `yaml:"type"`
+	Value  string `yaml:"value"`
+	Expiry int64  `yaml:"expiry,omitempty"`
 }
 
 type targetDetailsYAML struct {
 	Targets map[TargetName]TargetProps
 }
 
-func NewTarget(api string, insecure bool, token *TargetToken) TargetProps {
+type TargetAlreadyExistsError struct {
+	TargetName TargetName
+}
+
+func (err TargetAlreadyExistsError) Error() string {
+	return fmt.Sprintf("target already exists: %s", err.TargetName)
+}
+
+func NewTarget(api string, teamName string, insecure bool, token *TargetToken) TargetProps {
 	return TargetProps{
 		API:      strings.TrimRight(api, "/"),
+		TeamName: teamName,
 		Insecure: insecure,
 		Token:    token,
 	}
 }
 
-func SaveTarget(targetName TargetName, api string, insecure bool, token *TargetToken) error {
+func SaveTarget(targetName TargetName, api string, teamName string, insecure bool, token *TargetToken) error {
 	flyrc := filepath.Join(userHomeDir(), ".flyrc")
 	flyTargets, err := loadTargets(flyrc)
 	if err != nil {
@@ -63,6 +84,7 @@ func SaveTarget(targetName TargetName, api string, insecure bool, token *TargetT
 
 	newInfo := flyTargets.Targets[targetName]
 	newInfo.API = api
+	newInfo.TeamName = teamName
 	newInfo.Insecure = insecure
 	newInfo.Token = token
 
@@ -87,10 +109,90 @@ func SelectTarget(selectedTarget TargetName) (TargetProps, error) {
 		return TargetProps{}, UnknownTargetError{selectedTarget}
 	}
 
+	if target.TeamName == "" {
+		target.TeamName = DefaultTeamName
+	}
+
 	return target, nil
 }
 
-func NewClient(atcURL string, insecure bool) concourse.Client {
+func LoadTargets() (map[TargetName]TargetProps, error) {
+	flyrc := filepath.Join(userHomeDir(), ".flyrc")
+	flyTargets, err := loadTargets(flyrc)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, target := range flyTargets.Targets {
+		if target.TeamName == "" {
+			target.TeamName = DefaultTeamName
+			flyTargets.Targets[name] = target
+		}
+	}
+
+	return flyTargets.Targets, nil
+}
+
+func DeleteTarget(targetName TargetName) error {
+	return updateTargets(func(targets map[TargetName]TargetProps) error {
+		if _, ok := targets[targetName]; !ok {
+			return UnknownTargetError{targetName}
+		}
+
+		delete(targets, targetName)
+
+		return nil
+	})
+}
+
+func RenameTarget(oldName TargetName, newName TargetName) error {
+	return updateTargets(func(targets map[TargetName]TargetProps) error {
+		target, ok := targets[oldName]
+		if !ok {
+			return UnknownTargetError{oldName}
+		}
+
+		if _, exists := targets[newName]; exists {
+			return TargetAlreadyExistsError{newName}
+		}
+
+		delete(targets, oldName)
+		targets[newName] = target
+
+		return nil
+	})
+}
+
+func LogoutTarget(targetName TargetName) error {
+	return updateTargets(func(targets map[TargetName]TargetProps) error {
+		target, ok := targets[targetName]
+		if !ok {
+			return UnknownTargetError{targetName}
+		}
+
+		target.Token = nil
+		targets[targetName] = target
+
+		return nil
+	})
+}
+
+func updateTargets(update func(map[TargetName]TargetProps) error) error {
+	flyrc := filepath.Join(userHomeDir(), ".flyrc")
+	flyTargets, err := loadTargets(flyrc)
+	if err != nil {
+		return err
+	}
+
+	err = update(flyTargets.Targets)
+	if err != nil {
+		return err
+	}
+
+	return writeTargets(flyrc, flyTargets)
+}
+
+func NewClient(atcURL string, teamName string, insecure bool) concourse.Client {
 	var tlsConfig *tls.Config
 	if insecure {
 		tlsConfig = &tls.Config{InsecureSkipVerify: insecure}
@@ -105,6 +207,8 @@ func NewClient(atcURL string, insecure bool) concourse.Client {
 		}).Dial,
 	}
 
+	transport = newTeamTransport(atcURL, teamName, transport)
+
 	return concourse.NewClient(atcURL, &http.Client{
 		Transport: transport,
 	})
@@ -118,6 +222,14 @@ func TargetClient(selectedTarget TargetName) (concourse.Client, error) {
 	if isatty.IsTerminal(os.Stdout.Fd()) {
 		fmt.Printf("targeting %s\n\n", targetClient.URL())
 	}
+
+	target, err := SelectTarget(selectedTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	warnIfTokenExpiring(selectedTarget, target)
+
 	return targetClient, nil
 }
 
@@ -151,6 +263,8 @@ func CommandTargetClient(selectedTarget TargetName, commandInsecure *bool) (conc
 		}).Dial,
 	}
 
+	transport = newTeamTransport(target.API, target.TeamName, transport)
+
 	if token != nil {
 		transport = &oauth2.Transport{
 			Source: oauth2.StaticTokenSource(token),
@@ -165,6 +279,12 @@ func CommandTargetClient(selectedTarget TargetName, commandInsecure *bool) (conc
 	return concourse.NewClient(target.API, httpClient), nil
 }
 
+// CacheDir is where fly keeps data that is safe to delete, such as archives
+// of execute inputs.
+func CacheDir() string {
+	return filepath.Join(userHomeDir(), ".fly", "cache")
+}
+
 func userHomeDir() string {
 	if runtime.GOOS == "windows" {
 		home := os.Getenv("USERPROFILE")
@@ -201,6 +321,10 @@ func loadTargets(configFileLocation string) (*targetDetailsYAML, error) {
 		return &targetDetailsYAML{Targets: map[TargetName]TargetProps{}}, nil
 	}
 
+	if flyTargets.Targets == nil {
+		flyTargets.Targets = map[TargetName]TargetProps{}
+	}
+
 	return flyTargets, nil
 }
 
@@ -210,8 +334,29 @@ func writeTargets(configFileLocation string, targetsToWrite *targetDetailsYAML)
 		return fmt.Errorf("could not marshal %s", configFileLocation)
 	}
 
-	err = ioutil.WriteFile(configFileLocation, yamlBytes, os.ModePerm)
+	// write to a temporary file next to the real one and rename it into
+	// place, so that a failed write never leaves a truncated file behind
+	tmpFile, err := ioutil.TempFile(filepath.Dir(configFileLocation), ".flyrc")
+	if err != nil {
+		return fmt.Errorf("could not write %s", configFileLocation)
+	}
+
+	_, err = tmpFile.Write(yamlBytes)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), configFileLocation)
+	}
+
 	if err != nil {
+		os.Remove(tmpFile.Name())
 		return fmt.Errorf("could not write %s", configFileLocation)
 	}
 
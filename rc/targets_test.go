@@ -2,13 +2,16 @@ package rc_test
 
 import (
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 
+	"github.com/concourse/atc"
 	"github.com/concourse/fly/rc"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Targets", func() {
@@ -42,6 +45,7 @@ var _ = Describe("Targets", func() {
 				err := rc.SaveTarget(
 					targetName,
 					"some api url",
+					"main",
 					false,
 					nil,
 				)
@@ -63,6 +67,7 @@ var _ = Describe("Targets", func() {
 				err := rc.SaveTarget(
 					targetName,
 					"some api url",
+					"main",
 					true,
 					nil,
 				)
@@ -77,6 +82,204 @@ var _ = Describe("Targets", func() {
 		})
 	})
 
+	Describe("Team Name", func() {
+		var targetName rc.TargetName
+
+		BeforeEach(func() {
+			targetName = "foo"
+		})
+
+		Context("when a team name is saved with the target", func() {
+			BeforeEach(func() {
+				err := rc.SaveTarget(targetName, "some api url", "some-team", false, nil)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns the team name", func() {
+				returnedTarget, err := rc.SelectTarget(targetName)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(returnedTarget.TeamName).To(Equal("some-team"))
+			})
+		})
+
+		Context("when the target was saved without a team name", func() {
+			BeforeEach(func() {
+				err := ioutil.WriteFile(flyrc, []byte("targets:\n  foo:\n    api: some api url\n"), 0600)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns the default team name", func() {
+				returnedTarget, err := rc.SelectTarget(targetName)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(returnedTarget.TeamName).To(Equal(rc.DefaultTeamName))
+			})
+		})
+
+		Describe("requests made by the target's client", func() {
+			var atcServer *ghttp.Server
+
+			BeforeEach(func() {
+				atcServer = ghttp.NewServer()
+			})
+
+			AfterEach(func() {
+				atcServer.Close()
+			})
+
+			Context("when the target is for a team other than main", func() {
+				BeforeEach(func() {
+					err := rc.SaveTarget(targetName, atcServer.URL(), "some-team", false, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/teams/some-team/pipelines"),
+							ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{}),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/workers"),
+							ghttp.RespondWithJSONEncoded(200, []atc.Worker{}),
+						),
+					)
+				})
+
+				It("scopes team-owned routes to the team", func() {
+					client, err := rc.CommandTargetClient(targetName, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = client.ListPipelines()
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = client.ListWorkers()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(atcServer.ReceivedRequests()).To(HaveLen(2))
+				})
+			})
+
+			Context("when the team's name needs escaping", func() {
+				BeforeEach(func() {
+					err := rc.SaveTarget(targetName, atcServer.URL(), "some/team", false, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							func(w http.ResponseWriter, r *http.Request) {
+								Expect(r.URL.EscapedPath()).To(Equal("/api/v1/teams/some%2Fteam/pipelines"))
+							},
+							ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{}),
+						),
+					)
+				})
+
+				It("escapes it in the path", func() {
+					client, err := rc.CommandTargetClient(targetName, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = client.ListPipelines()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+
+			Context("when the target is for the main team", func() {
+				BeforeEach(func() {
+					err := rc.SaveTarget(targetName, atcServer.URL(), "main", false, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					atcServer.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest("GET", "/api/v1/pipelines"),
+							ghttp.RespondWithJSONEncoded(200, []atc.Pipeline{}),
+						),
+					)
+				})
+
+				It("leaves the routes untouched", func() {
+					client, err := rc.CommandTargetClient(targetName, nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = client.ListPipelines()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(atcServer.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	Describe("managing saved targets", func() {
+		BeforeEach(func() {
+			err := rc.SaveTarget("foo", "https://foo.example.com", "main", false, &rc.TargetToken{Type: "Bearer", Value: "foo-token"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = rc.SaveTarget("bar", "https://bar.example.com", "some-team", true, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("loads all of the targets", func() {
+			targets, err := rc.LoadTargets()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targets).To(HaveLen(2))
+			Expect(targets["foo"].API).To(Equal("https://foo.example.com"))
+			Expect(targets["bar"].TeamName).To(Equal("some-team"))
+		})
+
+		It("deletes a target", func() {
+			err := rc.DeleteTarget("foo")
+			Expect(err).ToNot(HaveOccurred())
+
+			targets, err := rc.LoadTargets()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targets).To(HaveLen(1))
+			Expect(targets).To(HaveKey(rc.TargetName("bar")))
+		})
+
+		It("renames a target", func() {
+			err := rc.RenameTarget("foo", "baz")
+			Expect(err).ToNot(HaveOccurred())
+
+			target, err := rc.SelectTarget("baz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.API).To(Equal("https://foo.example.com"))
+
+			_, err = rc.SelectTarget("foo")
+			Expect(err).To(Equal(rc.UnknownTargetError{"foo"}))
+		})
+
+		It("refuses to rename a target over an existing one", func() {
+			err := rc.RenameTarget("foo", "bar")
+			Expect(err).To(Equal(rc.TargetAlreadyExistsError{"bar"}))
+		})
+
+		It("logs out of a target by forgetting its token", func() {
+			err := rc.LogoutTarget("foo")
+			Expect(err).ToNot(HaveOccurred())
+
+			target, err := rc.SelectTarget("foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Token).To(BeNil())
+			Expect(target.API).To(Equal("https://foo.example.com"))
+		})
+
+		It("returns UnknownTargetError for targets that do not exist", func() {
+			Expect(rc.DeleteTarget("bogus")).To(Equal(rc.UnknownTargetError{"bogus"}))
+			Expect(rc.RenameTarget("bogus", "other")).To(Equal(rc.UnknownTargetError{"bogus"}))
+			Expect(rc.LogoutTarget("bogus")).To(Equal(rc.UnknownTargetError{"bogus"}))
+		})
+
+		It("does not leave temporary files behind", func() {
+			err := rc.DeleteTarget("foo")
+			Expect(err).ToNot(HaveOccurred())
+
+			entries, err := ioutil.ReadDir(tmpDir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal(".flyrc"))
+		})
+	})
+
 	Context("when selecting a target that does not exist", func() {
 		It("returns UnknownTargetError", func() {
 			_, err := rc.SelectTarget("bogus")
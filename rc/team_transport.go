@@ -0,0 +1,175 @@
+package rc
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/concourse/atc"
+	"github.com/tedsuo/rata"
+)
+
+const apiPrefix = "/api/v1"
+
+// teamScopedPrefixes are where the routes that the ATC serves per team, beneath
+// /api/v1/teams/:team_name, are found in its unscoped routes.
+var teamScopedPrefixes = []string{
+	apiPrefix + "/pipelines",
+	apiPrefix + "/auth/methods",
+	apiPrefix + "/auth/token",
+}
+
+// teamRoutes returns the ATC's routes, with those that it serves per team
+// moved beneath the given team.
+func teamRoutes(teamName string) rata.Routes {
+	// a leading colon would be taken for a param
+	escapedTeamName := strings.Replace(url.PathEscape(teamName), ":", "%3A", -1)
+
+	routes := make(rata.Routes, len(atc.Routes))
+
+	for i, route := range atc.Routes {
+		for _, prefix := range teamScopedPrefixes {
+			if route.Path == prefix || strings.HasPrefix(route.Path, prefix+"/") {
+				route.Path = apiPrefix + "/teams/" + escapedTeamName + strings.TrimPrefix(route.Path, apiPrefix)
+				break
+			}
+		}
+
+		routes[i] = route
+	}
+
+	return routes
+}
+
+// teamTransport sends each request made by a client to the route for the
+// target's team, rather than the default team's.
+type teamTransport struct {
+	// pathPrefix is the path of the ATC's URL, if it isn't served at the root
+	pathPrefix string
+
+	router http.Handler
+	routes rata.Routes
+
+	base http.RoundTripper
+}
+
+func newTeamTransport(atcURL string, teamName string, base http.RoundTripper) http.RoundTripper {
+	if teamName == "" || teamName == DefaultTeamName {
+		return base
+	}
+
+	var pathPrefix string
+	if parsedURL, err := url.Parse(atcURL); err == nil {
+		pathPrefix = strings.TrimSuffix(parsedURL.Path, "/")
+	}
+
+	handlers := rata.Handlers{}
+	for _, route := range atc.Routes {
+		handlers[route.Name] = matchedRoute(route.Name)
+	}
+
+	router, err := rata.NewRouter(atc.Routes, handlers)
+	if err != nil {
+		return base
+	}
+
+	return teamTransport{
+		pathPrefix: pathPrefix,
+		router:     router,
+		routes:     teamRoutes(teamName),
+		base:       base,
+	}
+}
+
+func (t teamTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(r.URL.Path, t.pathPrefix+apiPrefix+"/") {
+		return t.base.RoundTrip(r)
+	}
+
+	match := t.match(r)
+	if match.name == "" {
+		return t.base.RoundTrip(r)
+	}
+
+	// escaped so that the path can be parsed back without losing the meaning
+	// of a slash within a name
+	escapedParams := rata.Params{}
+	for name, value := range match.params {
+		escapedParams[name] = url.PathEscape(value)
+	}
+
+	path, err := t.routes.CreatePathForRoute(match.name, escapedParams)
+	if err != nil {
+		return nil, err
+	}
+
+	generatedURL, err := url.Parse(t.pathPrefix + path)
+	if err != nil {
+		return nil, err
+	}
+
+	scopedURL := *r.URL
+	scopedURL.Path = generatedURL.Path
+	scopedURL.RawPath = generatedURL.RawPath
+
+	scopedRequest := new(http.Request)
+	*scopedRequest = *r
+	scopedRequest.URL = &scopedURL
+
+	return t.base.RoundTrip(scopedRequest)
+}
+
+// match finds the ATC route that the request was generated from.
+func (t teamTransport) match(r *http.Request) *routeMatch {
+	routedURL := *r.URL
+	routedURL.Path = strings.TrimPrefix(r.URL.Path, t.pathPrefix)
+	routedURL.RawPath = ""
+	routedURL.RawQuery = ""
+
+	routedRequest := new(http.Request)
+	*routedRequest = *r
+	routedRequest.URL = &routedURL
+
+	match := &routeMatch{}
+	t.router.ServeHTTP(match, routedRequest)
+
+	return match
+}
+
+// routeMatch records the route that the router matched, in place of a
+// response.
+type routeMatch struct {
+	name   string
+	params rata.Params
+
+	header http.Header
+}
+
+func matchedRoute(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match, ok := w.(*routeMatch)
+		if !ok {
+			return
+		}
+
+		match.name = name
+		match.params = rata.Params{}
+
+		for key, values := range r.URL.Query() {
+			if strings.HasPrefix(key, ":") && len(values) > 0 {
+				match.params[key[1:]] = values[0]
+			}
+		}
+	})
+}
+
+func (match *routeMatch) Header() http.Header {
+	if match.header == nil {
+		match.header = http.Header{}
+	}
+
+	return match.header
+}
+
+func (match *routeMatch) Write(payload []byte) (int, error) { return len(payload), nil }
+func (match *routeMatch) WriteHeader(int)                   {}
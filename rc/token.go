@@ -0,0 +1,73 @@
+package rc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// TokenExpiryWarning is how long before a target's token expires that
+// commands start warning about it.
+const TokenExpiryWarning = 2 * time.Hour
+
+// TokenExpiry reads the expiry out of a JWT token value. Opaque tokens, or
+// tokens that carry no expiry, report false.
+func TokenExpiry(value string) (time.Time, bool) {
+	segments := strings.Split(value, ".")
+	if len(segments) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.URLEncoding.DecodeString(padBase64(segments[1]))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Expiry int64 `json:"exp"`
+	}
+
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || claims.Expiry == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Expiry, 0), true
+}
+
+func (token *TargetToken) ExpiresAt() (time.Time, bool) {
+	if token == nil || token.Expiry == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(token.Expiry, 0), true
+}
+
+func warnIfTokenExpiring(targetName TargetName, target TargetProps) {
+	expiresAt, ok := target.Token.ExpiresAt()
+	if !ok {
+		return
+	}
+
+	remaining := expiresAt.Sub(time.Now())
+	if remaining > TokenExpiryWarning {
+		return
+	}
+
+	if remaining <= 0 {
+		fmt.Fprintf(os.Stderr, "warning: your token for target '%s' has expired. run `fly -t %s login` to renew it\n\n", targetName, targetName)
+	} else {
+		fmt.Fprintf(os.Stderr, "warning: your token for target '%s' expires in %s. run `fly -t %s login` to renew it\n\n", targetName, remaining-(remaining%time.Minute), targetName)
+	}
+}
+
+func padBase64(segment string) string {
+	if m := len(segment) % 4; m != 0 {
+		segment += strings.Repeat("=", 4-m)
+	}
+
+	return segment
+}
@@ -0,0 +1,64 @@
+package rc_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/concourse/fly/rc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tokens", func() {
+	jwtWithClaims := func(claims string) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+		payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+		return header + "." + payload + ".signature"
+	}
+
+	Describe("TokenExpiry", func() {
+		It("reads the expiry from a JWT", func() {
+			expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+			expiry, ok := rc.TokenExpiry(jwtWithClaims(fmt.Sprintf(`{"exp":%d,"teamName":"main"}`, expiresAt.Unix())))
+			Expect(ok).To(BeTrue())
+			Expect(expiry.Unix()).To(Equal(expiresAt.Unix()))
+		})
+
+		It("reports no expiry for a JWT without an exp claim", func() {
+			_, ok := rc.TokenExpiry(jwtWithClaims(`{"teamName":"main"}`))
+			Expect(ok).To(BeFalse())
+		})
+
+		It("reports no expiry for an opaque token", func() {
+			_, ok := rc.TokenExpiry("some-opaque-token")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("ExpiresAt", func() {
+		It("returns the recorded expiry", func() {
+			token := &rc.TargetToken{Type: "Bearer", Value: "some-token", Expiry: 1234}
+
+			expiresAt, ok := token.ExpiresAt()
+			Expect(ok).To(BeTrue())
+			Expect(expiresAt).To(Equal(time.Unix(1234, 0)))
+		})
+
+		It("returns false when no expiry was recorded", func() {
+			token := &rc.TargetToken{Type: "Bearer", Value: "some-token"}
+
+			_, ok := token.ExpiresAt()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns false for a missing token", func() {
+			var token *rc.TargetToken
+
+			_, ok := token.ExpiresAt()
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,328 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// DefaultEnvPrefix is what the names of the environment variables read by an
+// EnvProvider start with, unless told otherwise.
+const DefaultEnvPrefix = "FLY_VAR_"
+
+// KeyEnv is the environment variable holding the key for encrypted vars files.
+const KeyEnv = "FLY_VARS_KEY"
+
+// A Provider resolves template variables from somewhere other than the
+// command line, such as a secret store, so that they needn't be kept on disk
+// in plain text.
+type Provider interface {
+	// Variables returns the values of whichever of the named variables the
+	// provider knows.
+	Variables(names []string) (Variables, error)
+}
+
+// ParseProvider returns the provider described by a spec: 'env' or
+// 'env:PREFIX' for environment variables, 'encrypted:PATH' for a vars file
+// encrypted with the key in $FLY_VARS_KEY, or 'command:COMMAND' for a command
+// that prints the variables as JSON. The command is split into words the way
+// a shell would, so arguments containing spaces may be quoted.
+func ParseProvider(spec string) (Provider, error) {
+	segments := strings.SplitN(spec, ":", 2)
+
+	kind := segments[0]
+
+	var argument string
+	hasArgument := len(segments) == 2
+	if hasArgument {
+		argument = segments[1]
+	}
+
+	switch kind {
+	case "env":
+		prefix := DefaultEnvPrefix
+		if hasArgument {
+			prefix = argument
+		}
+
+		return EnvProvider{Prefix: prefix}, nil
+
+	case "encrypted":
+		if argument == "" {
+			return nil, errors.New("the encrypted vars provider needs a path, as in encrypted:PATH")
+		}
+
+		return EncryptedFileProvider{Path: argument}, nil
+
+	case "command":
+		command, err := splitWords(argument)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command for the command vars provider: %s", err)
+		}
+
+		if len(command) == 0 {
+			return nil, errors.New("the command vars provider needs a command, as in command:COMMAND")
+		}
+
+		return CommandProvider{Command: command}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown vars provider '%s' (must be env, encrypted or command)", kind)
+	}
+}
+
+// ResolveVariables returns the variables referenced by the content from each
+// of the providers in turn, with later providers taking precedence. Only the
+// variables that aren't already bound are asked for, and the providers aren't
+// used at all if every variable is.
+func ResolveVariables(content []byte, providers []Provider, bound Variables) (Variables, error) {
+	resolved := Variables{}
+
+	if len(providers) == 0 {
+		return resolved, nil
+	}
+
+	names := unboundNames(content, bound)
+	if len(names) == 0 {
+		return resolved, nil
+	}
+
+	for _, provider := range providers {
+		variables, err := provider.Variables(names)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved = resolved.Merge(variables)
+	}
+
+	return resolved, nil
+}
+
+// ReferencedNames returns the names of the variables referenced by the
+// content. A dotted path like 'aws.region' is returned along with the name of
+// the variable it starts with, 'aws', so that a provider may give either.
+func ReferencedNames(content []byte) []string {
+	names := []string{}
+	seen := map[string]bool{}
+
+	add := func(name string) {
+		if !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+
+	for _, groups := range templateFormatRegex.FindAllSubmatch(content, -1) {
+		name := string(groups[4])
+		if groups[2] != nil {
+			name = string(groups[2])
+		}
+
+		add(name)
+
+		if root := strings.SplitN(name, ".", 2)[0]; root != name {
+			add(root)
+		}
+	}
+
+	return names
+}
+
+// unboundNames is like ReferencedNames, but leaves out the variables, and the
+// variables that dotted paths start with, that are already bound.
+func unboundNames(content []byte, bound Variables) []string {
+	names := []string{}
+
+	for _, name := range ReferencedNames(content) {
+		if _, found := bound.Lookup(name); !found {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// splitWords splits a command into words the way a shell would, honouring
+// single and double quotes and backslash escapes, but without expanding
+// anything.
+func splitWords(command string) ([]string, error) {
+	words := []string{}
+
+	var word bytes.Buffer
+	inWord := false
+
+	var quote rune
+	escaped := false
+
+	for _, c := range command {
+		switch {
+		case escaped:
+			// within double quotes, a backslash only escapes what the shell
+			// would otherwise treat specially
+			if quote == '"' && !strings.ContainsRune("\\\"$`", c) {
+				word.WriteRune('\\')
+			}
+
+			word.WriteRune(c)
+			escaped = false
+
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("unfinished escape at the end")
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+var envUnsafeCharsRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// EnvProvider reads variables from environment variables named after them,
+// upper-cased with anything but letters and digits replaced by underscores.
+type EnvProvider struct {
+	Prefix string
+
+	// LookupEnv defaults to os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+}
+
+func (provider EnvProvider) Variables(names []string) (Variables, error) {
+	lookupEnv := provider.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	variables := Variables{}
+
+	for _, name := range names {
+		envName := provider.Prefix + strings.ToUpper(envUnsafeCharsRegex.ReplaceAllString(name, "_"))
+
+		if value, found := lookupEnv(envName); found {
+			variables[name] = value
+		}
+	}
+
+	return variables, nil
+}
+
+// EncryptedFileProvider reads variables from a YAML vars file encrypted with
+// EncryptVariables.
+type EncryptedFileProvider struct {
+	Path string
+
+	// Key defaults to the key in $FLY_VARS_KEY.
+	Key []byte
+}
+
+func (provider EncryptedFileProvider) Variables(names []string) (Variables, error) {
+	key := provider.Key
+	if key == nil {
+		var err error
+		key, err = ParseKey(os.Getenv(KeyEnv))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in $%s: %s", KeyEnv, err)
+		}
+	}
+
+	contents, err := ioutil.ReadFile(provider.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := DecryptVariables(contents, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %s", provider.Path, err)
+	}
+
+	variables, err := parseVariables(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", provider.Path, err)
+	}
+
+	return variables, nil
+}
+
+// CommandProvider runs a command with the names of the variables as
+// arguments, and reads their values from the JSON object it prints. This is
+// meant for wrapping a password manager or a secret store's CLI. Whatever the
+// command writes to stderr, such as a prompt, is shown.
+type CommandProvider struct {
+	Command []string
+
+	// Run defaults to running the command.
+	Run func(command []string) ([]byte, error)
+}
+
+func (provider CommandProvider) Variables(names []string) (Variables, error) {
+	run := provider.Run
+	if run == nil {
+		run = runCommand
+	}
+
+	command := append(append([]string{}, provider.Command...), names...)
+
+	output, err := run(command)
+	if err != nil {
+		return nil, fmt.Errorf("vars provider command '%s' failed: %s", provider.Command[0], err)
+	}
+
+	var variables Variables
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	decoder.UseNumber()
+
+	err = decoder.Decode(&variables)
+	if err != nil {
+		return nil, fmt.Errorf("vars provider command '%s' did not print a JSON object: %s", provider.Command[0], err)
+	}
+
+	return variables, nil
+}
+
+func runCommand(command []string) ([]byte, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	return cmd.Output()
+}
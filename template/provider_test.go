@@ -0,0 +1,271 @@
+package template_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/concourse/fly/template"
+)
+
+type fakeProvider struct {
+	variables template.Variables
+	err       error
+
+	requestedNames []string
+}
+
+func (provider *fakeProvider) Variables(names []string) (template.Variables, error) {
+	provider.requestedNames = names
+	return provider.variables, provider.err
+}
+
+var _ = Describe("Providers", func() {
+	Describe("ParseProvider", func() {
+		It("parses environment variable providers", func() {
+			provider, err := template.ParseProvider("env")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provider).To(Equal(template.EnvProvider{Prefix: "FLY_VAR_"}))
+
+			provider, err = template.ParseProvider("env:CI_")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provider).To(Equal(template.EnvProvider{Prefix: "CI_"}))
+		})
+
+		It("parses encrypted file providers", func() {
+			provider, err := template.ParseProvider("encrypted:ci/secrets.yml.enc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provider).To(Equal(template.EncryptedFileProvider{Path: "ci/secrets.yml.enc"}))
+		})
+
+		It("parses command providers", func() {
+			provider, err := template.ParseProvider("command:pass-vars --store ci")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provider).To(Equal(template.CommandProvider{Command: []string{"pass-vars", "--store", "ci"}}))
+		})
+
+		It("splits the command the way a shell would", func() {
+			provider, err := template.ParseProvider(`command:"/opt/my tools/vars" --store 'ci secrets' --prefix "team \"a\"" with\ space ''`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provider).To(Equal(template.CommandProvider{Command: []string{
+				"/opt/my tools/vars", "--store", "ci secrets", "--prefix", `team "a"`, "with space", "",
+			}}))
+		})
+
+		It("errors when the command's quotes are unterminated", func() {
+			_, err := template.ParseProvider(`command:vars --store 'ci`)
+			Expect(err).To(MatchError("invalid command for the command vars provider: unterminated ' quote"))
+		})
+
+		It("errors for unknown providers", func() {
+			_, err := template.ParseProvider("vault")
+			Expect(err).To(MatchError("unknown vars provider 'vault' (must be env, encrypted or command)"))
+		})
+
+		It("errors when a provider is missing its argument", func() {
+			_, err := template.ParseProvider("encrypted")
+			Expect(err).To(HaveOccurred())
+
+			_, err = template.ParseProvider("command:")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ReferencedNames", func() {
+		It("returns each variable referenced, along with the root of each path", func() {
+			content := []byte(`
+password: {{db-password}}
+region: {{aws.region:-us-east-1}}
+again: {{db-password}}
+source:
+  {{...git}}
+`)
+
+			Expect(template.ReferencedNames(content)).To(Equal([]string{"db-password", "aws.region", "aws", "git"}))
+		})
+	})
+
+	Describe("ResolveVariables", func() {
+		It("merges the variables from each provider, the later taking precedence", func() {
+			first := &fakeProvider{variables: template.Variables{"a": "first", "b": "first"}}
+			second := &fakeProvider{variables: template.Variables{"b": "second"}}
+
+			variables, err := template.ResolveVariables([]byte("{{a}} {{b}}"), []template.Provider{first, second}, template.Variables{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{"a": "first", "b": "second"}))
+
+			Expect(first.requestedNames).To(Equal([]string{"a", "b"}))
+			Expect(second.requestedNames).To(Equal([]string{"a", "b"}))
+		})
+
+		It("returns the first error", func() {
+			failing := &fakeProvider{err: errors.New("disaster")}
+
+			_, err := template.ResolveVariables([]byte("{{a}}"), []template.Provider{failing}, template.Variables{})
+			Expect(err).To(MatchError("disaster"))
+		})
+
+		It("only asks for the variables that aren't already bound", func() {
+			provider := &fakeProvider{variables: template.Variables{"b": "provided"}}
+
+			content := []byte("{{a}} {{b}} {{aws.region}} {{aws.zone}}")
+			bound := template.Variables{
+				"a":   "given",
+				"aws": map[string]interface{}{"region": "us-east-1"},
+			}
+
+			_, err := template.ResolveVariables(content, []template.Provider{provider}, bound)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(provider.requestedNames).To(Equal([]string{"b", "aws.zone"}))
+		})
+
+		It("does not use the providers when every variable is bound", func() {
+			failing := &fakeProvider{err: errors.New("disaster")}
+
+			variables, err := template.ResolveVariables([]byte("{{a}}"), []template.Provider{failing}, template.Variables{"a": "given"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(BeEmpty())
+
+			Expect(failing.requestedNames).To(BeNil())
+		})
+	})
+
+	Describe("EnvProvider", func() {
+		It("reads the variables from environment variables named after them", func() {
+			env := map[string]string{
+				"FLY_VAR_DB_PASSWORD": "hunter2",
+				"FLY_VAR_AWS_REGION":  "eu-west-1",
+			}
+
+			provider := template.EnvProvider{
+				Prefix: "FLY_VAR_",
+				LookupEnv: func(name string) (string, bool) {
+					value, found := env[name]
+					return value, found
+				},
+			}
+
+			variables, err := provider.Variables([]string{"db-password", "aws.region", "aws", "missing"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{
+				"db-password": "hunter2",
+				"aws.region":  "eu-west-1",
+			}))
+		})
+	})
+
+	Describe("CommandProvider", func() {
+		var ranCommand []string
+		var output []byte
+		var runErr error
+
+		var provider template.CommandProvider
+
+		BeforeEach(func() {
+			ranCommand = nil
+			output = []byte(`{"db-password": "hunter2", "db": {"port": 5432}}`)
+			runErr = nil
+
+			provider = template.CommandProvider{
+				Command: []string{"pass-vars", "--store", "ci"},
+				Run: func(command []string) ([]byte, error) {
+					ranCommand = command
+					return output, runErr
+				},
+			}
+		})
+
+		It("runs the command with the names and reads the JSON it prints", func() {
+			variables, err := provider.Variables([]string{"db-password", "db"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ranCommand).To(Equal([]string{"pass-vars", "--store", "ci", "db-password", "db"}))
+
+			value, found := variables.Lookup("db.port")
+			Expect(found).To(BeTrue())
+			Expect(value).To(BeEquivalentTo("5432"))
+
+			Expect(variables["db-password"]).To(Equal("hunter2"))
+		})
+
+		It("errors when the command fails", func() {
+			runErr = errors.New("exit status 1")
+
+			_, err := provider.Variables([]string{"db-password"})
+			Expect(err).To(MatchError("vars provider command 'pass-vars' failed: exit status 1"))
+		})
+
+		It("errors when the command does not print JSON", func() {
+			output = []byte("hunter2")
+
+			_, err := provider.Variables([]string{"db-password"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("did not print a JSON object"))
+		})
+	})
+
+	Describe("EncryptedFileProvider", func() {
+		var tmpdir string
+		var key []byte
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir("", "encrypted-vars")
+			Expect(err).NotTo(HaveOccurred())
+
+			encodedKey, err := template.GenerateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			key, err = template.ParseKey(encodedKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			ciphertext, err := template.EncryptVariables([]byte("db-password: hunter2\nreplicas: 3\n"), key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(ciphertext)).NotTo(ContainSubstring("hunter2"))
+
+			err = ioutil.WriteFile(filepath.Join(tmpdir, "vars.yml.enc"), ciphertext, 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		It("reads the variables from the decrypted file", func() {
+			provider := template.EncryptedFileProvider{Path: filepath.Join(tmpdir, "vars.yml.enc"), Key: key}
+
+			variables, err := provider.Variables([]string{"db-password"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{
+				"db-password": "hunter2",
+				"replicas":    "3",
+			}))
+		})
+
+		It("errors when given the wrong key", func() {
+			otherKey, err := template.GenerateKey()
+			Expect(err).NotTo(HaveOccurred())
+
+			wrongKey, err := template.ParseKey(otherKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			provider := template.EncryptedFileProvider{Path: filepath.Join(tmpdir, "vars.yml.enc"), Key: wrongKey}
+
+			_, err = provider.Variables([]string{"db-password"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to decrypt"))
+		})
+	})
+
+	Describe("ParseKey", func() {
+		It("rejects keys of the wrong size", func() {
+			_, err := template.ParseKey("c2hvcnQ=")
+			Expect(err).To(MatchError("key must be 32 bytes, not 5"))
+		})
+	})
+})
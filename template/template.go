@@ -2,28 +2,159 @@ package template
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 )
 
-var templateFormatRegex = regexp.MustCompile(`\{\{([-\w\p{L}]+)\}\}`)
+// templateFormatRegex matches either a splice, '{{...name}}', alone on its
+// line, or a value, '{{name}}'. Either may give a default after ':-', as in
+// '{{name:-default}}'. A name starts with a letter, a digit or an underscore,
+// so that text for other template languages, like Go's '{{.Name}}' or
+// mustache's '{{#section}}', is left alone.
+var templateFormatRegex = regexp.MustCompile(
+	`(?m)^([ \t]*)\{\{\.\.\.([\w\p{L}][-\w\p{L}.]*)(?::-(.*?))?\}\}[ \t]*$` +
+		`|\{\{([\w\p{L}][-\w\p{L}.]*)(?::-(.*?))?\}\}`,
+)
+
+// A Substitution is a variable that was templated into the content, so that
+// where a value came from can be told afterwards, e.g. to hide secrets.
+type Substitution struct {
+	Name  string
+	Value interface{}
+}
 
+// Evaluate fills in the variables referenced by the content. A variable is
+// referenced as '{{name}}' and is replaced by its value encoded as JSON,
+// which is also YAML, so that numbers, booleans, lists and maps keep their
+// types. Fields within variables are referenced by a dotted path, as in
+// '{{aws.region}}'.
+//
+// A default for when the variable isn't set follows ':-' and is parsed as
+// YAML, as in '{{replicas:-3}}'.
+//
+// A map or a list can be spliced into the surrounding YAML, as its keys or
+// its items, with '{{...name}}' on a line of its own.
 func Evaluate(content []byte, variables Variables) ([]byte, error) {
+	result, _, err := EvaluateTracked(content, variables)
+	return result, err
+}
+
+// EvaluateTracked is like Evaluate, but also returns each substitution it
+// made, in the order they appear in the content.
+func EvaluateTracked(content []byte, variables Variables) ([]byte, []Substitution, error) {
 	var variableErrors error
 
-	return templateFormatRegex.ReplaceAllFunc(content, func(match []byte) []byte {
-		key := string(templateFormatRegex.FindSubmatch(match)[1])
+	substitutions := []Substitution{}
+
+	result := templateFormatRegex.ReplaceAllFunc(content, func(match []byte) []byte {
+		groups := templateFormatRegex.FindSubmatch(match)
+
+		splice := groups[2] != nil
+
+		name, defaultValue := groups[4], groups[5]
+		if splice {
+			name, defaultValue = groups[2], groups[3]
+		}
 
-		value, found := variables[key]
-		if !found {
-			variableErrors = multierror.Append(variableErrors, fmt.Errorf("unbound variable in template: '%s'", key))
+		key := string(name)
+
+		value, err := lookup(variables, key, defaultValue)
+		if err != nil {
+			variableErrors = multierror.Append(variableErrors, err)
 			return match
 		}
 
-		saveValue, _ := json.Marshal(value)
+		var replacement []byte
+		if splice {
+			replacement, err = spliceValue(string(groups[1]), value)
+			if err != nil {
+				variableErrors = multierror.Append(variableErrors, fmt.Errorf("cannot splice '%s': %s", key, err))
+				return match
+			}
+		} else {
+			replacement, err = json.Marshal(value)
+			if err != nil {
+				variableErrors = multierror.Append(variableErrors, fmt.Errorf("cannot encode '%s': %s", key, err))
+				return match
+			}
+		}
+
+		substitutions = append(substitutions, Substitution{Name: key, Value: value})
+
+		return replacement
+	})
+
+	return result, substitutions, variableErrors
+}
+
+func lookup(variables Variables, key string, defaultValue []byte) (interface{}, error) {
+	value, found := variables.Lookup(key)
+	if found {
+		return value, nil
+	}
+
+	if defaultValue == nil {
+		return nil, fmt.Errorf("unbound variable in template: '%s'", key)
+	}
+
+	if len(defaultValue) == 0 {
+		return "", nil
+	}
+
+	value, err := ParseValue(string(defaultValue))
+	if err != nil {
+		return nil, fmt.Errorf("invalid default for '%s': %s", key, err)
+	}
+
+	return value, nil
+}
+
+// spliceValue renders the keys of a map or the items of a list as lines of
+// YAML at the given indentation.
+func spliceValue(indent string, value interface{}) ([]byte, error) {
+	lines := []string{}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			encodedKey, err := json.Marshal(key)
+			if err != nil {
+				return nil, err
+			}
+
+			encodedValue, err := json.Marshal(v[key])
+			if err != nil {
+				return nil, err
+			}
+
+			lines = append(lines, fmt.Sprintf("%s%s: %s", indent, encodedKey, encodedValue))
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			encodedItem, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+
+			lines = append(lines, fmt.Sprintf("%s- %s", indent, encodedItem))
+		}
+
+	default:
+		return nil, errors.New("it is neither a map nor a list")
+	}
 
-		return []byte(saveValue)
-	}), variableErrors
+	return []byte(strings.Join(lines, "\n")), nil
 }
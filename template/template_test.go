@@ -76,6 +76,14 @@ var _ = Describe("Template", func() {
 		Expect(result).To(Equal([]byte(`"this\nhas\nmany\nlines"`)))
 	})
 
+	It("leaves text for other template languages alone", func() {
+		byteSlice := []byte("{{.State.Running}} {{ .Name }} {{#items}}{{/items}} {{^empty}} {{! comment}} {{> partial}} {{&raw}} {{foo:bar}}")
+
+		result, err := template.Evaluate(byteSlice, template.Variables{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(byteSlice))
+	})
+
 	It("raises an error for each variable that is undefined", func() {
 		byteSlice := []byte("{{not-specified-one}}{{not-specified-two}}")
 		variables := template.Variables{}
@@ -97,4 +105,145 @@ var _ = Describe("Template", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(result).To(Equal([]byte("{{}")))
 	})
+
+	Describe("tracking substitutions", func() {
+		It("returns each variable that was templated in", func() {
+			byteSlice := []byte("{{user}}:{{password}}@{{user}}")
+			variables := template.Variables{
+				"user":     "admin",
+				"password": "hunter2",
+				"unused":   "nope",
+			}
+
+			result, substitutions, err := template.EvaluateTracked(byteSlice, variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`"admin":"hunter2"@"admin"`)))
+			Expect(substitutions).To(Equal([]template.Substitution{
+				{Name: "user", Value: "admin"},
+				{Name: "password", Value: "hunter2"},
+				{Name: "user", Value: "admin"},
+			}))
+		})
+
+		It("leaves out unbound variables", func() {
+			_, substitutions, err := template.EvaluateTracked([]byte("{{bound}}{{unbound}}"), template.Variables{"bound": "value"})
+			Expect(err).To(HaveOccurred())
+			Expect(substitutions).To(Equal([]template.Substitution{
+				{Name: "bound", Value: "value"},
+			}))
+		})
+	})
+
+	Describe("typed values", func() {
+		It("templates numbers, booleans, lists and maps as themselves", func() {
+			byteSlice := []byte("{{replicas}} {{enabled}} {{tags}} {{source}}")
+			variables := template.Variables{
+				"replicas": 3,
+				"enabled":  true,
+				"tags":     []interface{}{"a", "b"},
+				"source":   map[interface{}]interface{}{"bucket": "some-bucket"},
+			}
+
+			result, err := template.Evaluate(byteSlice, variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`3 true ["a","b"] {"bucket":"some-bucket"}`)))
+		})
+	})
+
+	Describe("dotted paths", func() {
+		var variables template.Variables
+
+		BeforeEach(func() {
+			var err error
+			variables, err = template.LoadVariablesFromFile("fixtures/structured_vars.yml")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("looks up fields of maps and items of lists", func() {
+			result, err := template.Evaluate([]byte("{{aws.region}} {{aws.subnets.1}}"), variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`"us-east-1" "subnet-b"`)))
+		})
+
+		It("prefers a variable whose whole name matches", func() {
+			variables["aws.region"] = "eu-west-1"
+
+			result, err := template.Evaluate([]byte("{{aws.region}}"), variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`"eu-west-1"`)))
+		})
+
+		It("raises an error for a path that doesn't exist", func() {
+			_, err := template.Evaluate([]byte("{{aws.zone}}{{aws.subnets.5}}"), variables)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unbound variable in template: 'aws.zone'"))
+			Expect(err.Error()).To(ContainSubstring("unbound variable in template: 'aws.subnets.5'"))
+		})
+	})
+
+	Describe("defaults", func() {
+		It("uses the default when the variable isn't set", func() {
+			result, err := template.Evaluate([]byte("{{branch:-master}} {{replicas:-3}} {{tags:-[a, b]}}"), template.Variables{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`"master" 3 ["a","b"]`)))
+		})
+
+		It("uses the variable when it is set", func() {
+			result, err := template.Evaluate([]byte("{{branch:-master}}"), template.Variables{"branch": "develop"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`"develop"`)))
+		})
+
+		It("defaults to an empty string when nothing follows the ':-'", func() {
+			result, err := template.Evaluate([]byte("{{suffix:-}}"), template.Variables{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]byte(`""`)))
+		})
+	})
+
+	Describe("splicing", func() {
+		It("splices the keys of a map into the surrounding map", func() {
+			byteSlice := []byte("source:\n  {{...credentials}}\n  bucket: some-bucket\n")
+			variables := template.Variables{
+				"credentials": map[string]interface{}{
+					"access_key_id":     "some-id",
+					"secret_access_key": "some-key",
+				},
+			}
+
+			result, err := template.Evaluate(byteSlice, variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal(
+				"source:\n" +
+					"  \"access_key_id\": \"some-id\"\n" +
+					"  \"secret_access_key\": \"some-key\"\n" +
+					"  bucket: some-bucket\n",
+			))
+		})
+
+		It("splices the items of a list into the surrounding list", func() {
+			byteSlice := []byte("plan:\n- get: repo\n{{...steps}}\n")
+			variables := template.Variables{
+				"steps": []interface{}{
+					map[string]interface{}{"task": "unit"},
+					map[string]interface{}{"task": "lint"},
+				},
+			}
+
+			result, err := template.Evaluate(byteSlice, variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal(
+				"plan:\n" +
+					"- get: repo\n" +
+					"- {\"task\":\"unit\"}\n" +
+					"- {\"task\":\"lint\"}\n",
+			))
+		})
+
+		It("raises an error when splicing something else", func() {
+			_, err := template.Evaluate([]byte("{{...name}}\n"), template.Variables{"name": "value"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot splice 'name': it is neither a map nor a list"))
+		})
+	})
 })
@@ -1,12 +1,18 @@
 package template
 
 import (
+	"fmt"
 	"io/ioutil"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
-type Variables map[string]string
+// Variables map names to values, which may be strings, numbers, booleans, or
+// lists and maps of them. Values loaded from files are strings, or lists and
+// maps of strings; typed values are only given explicitly, with ParseValue.
+type Variables map[string]interface{}
 
 func (v Variables) Merge(other Variables) Variables {
 	merged := Variables{}
@@ -22,18 +28,145 @@ func (v Variables) Merge(other Variables) Variables {
 	return merged
 }
 
+// Lookup finds the value of a variable, or of a field within one given by a
+// dotted path like 'aws.region'. Lists are indexed by number, like
+// 'subnets.0'. A variable whose whole name matches takes precedence, so
+// names containing dots still work.
+func (v Variables) Lookup(path string) (interface{}, bool) {
+	if value, found := v[path]; found {
+		return normalize(value), true
+	}
+
+	segments := strings.Split(path, ".")
+
+	value, found := v[segments[0]]
+	if !found {
+		return nil, false
+	}
+
+	value = normalize(value)
+
+	for _, segment := range segments[1:] {
+		switch container := value.(type) {
+		case map[string]interface{}:
+			value, found = container[segment]
+			if !found {
+				return nil, false
+			}
+
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(container) {
+				return nil, false
+			}
+
+			value = container[index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
 func LoadVariablesFromFile(path string) (Variables, error) {
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		return Variables{}, err
 	}
 
-	var variables Variables
+	return parseVariables(contents)
+}
+
+func parseVariables(contents []byte) (Variables, error) {
+	var variables map[string]textValue
 
-	err = yaml.Unmarshal(contents, &variables)
+	err := yaml.Unmarshal(contents, &variables)
 	if err != nil {
 		return Variables{}, err
 	}
 
-	return variables, nil
+	loaded := Variables{}
+	for name, value := range variables {
+		loaded[name] = value.value
+	}
+
+	return loaded, nil
+}
+
+// textValue decodes each scalar as the text it was written as, so that
+// 'version: 1.10' stays "1.10" rather than becoming the number 1.1, while
+// keeping maps and lists structured.
+type textValue struct {
+	value interface{}
+}
+
+func (t *textValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err == nil {
+		t.value = text
+		return nil
+	}
+
+	var items []textValue
+	if err := unmarshal(&items); err == nil {
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			list[i] = item.value
+		}
+
+		t.value = list
+		return nil
+	}
+
+	var fields map[string]textValue
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+
+	normalized := map[string]interface{}{}
+	for key, field := range fields {
+		normalized[key] = field.value
+	}
+
+	t.value = normalized
+	return nil
+}
+
+// ParseValue parses a value given as YAML, such as '3', 'true' or '[a, b]'.
+func ParseValue(value string) (interface{}, error) {
+	var parsed interface{}
+
+	err := yaml.Unmarshal([]byte(value), &parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize(parsed), nil
+}
+
+// normalize converts the maps that YAML decodes objects into to maps with
+// string keys, so that they can be looked up by path and encoded as JSON.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := map[string]interface{}{}
+		for key, val := range v {
+			normalized[fmt.Sprintf("%v", key)] = normalize(val)
+		}
+
+		return normalized
+
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, val := range v {
+			normalized[i] = normalize(val)
+		}
+
+		return normalized
+
+	default:
+		return value
+	}
 }
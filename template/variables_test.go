@@ -71,6 +71,20 @@ var _ = Describe("Variables", func() {
 
 		})
 
+		It("keeps the structure of the values, reading each as written", func() {
+			variables, err := template.LoadVariablesFromFile("fixtures/structured_vars.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(template.Variables{
+				"aws": map[string]interface{}{
+					"region":  "us-east-1",
+					"subnets": []interface{}{"subnet-a", "subnet-b"},
+				},
+				"replicas": "3",
+				"enabled":  "true",
+				"version":  "1.10",
+			}))
+		})
+
 		It("returns an error if the file does not exist", func() {
 			_, err := template.LoadVariablesFromFile("fixtures/missing.yml")
 			Expect(err).To(HaveOccurred())